@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k-p5w/go-marybot/internal/command"
+	"github.com/k-p5w/go-marybot/internal/helix"
+)
+
+const botMessageFile = "botMessage.json"
+
+// BotMessage は botMessage.json の構造体
+type BotMessage struct {
+	OnConnect           string            `json:"OnConnect"`
+	OnUserJoinMessage   string            `json:"OnUserJoinMessage"`   // 初チャットの挨拶（日本語、%v にユーザー名）
+	OnUserJoinMessageEN string            `json:"OnUserJoinMessageEN"` // 初チャットの挨拶（英語）
+	GreetIgnore         []string          `json:"GreetIgnore"`         // 挨拶しないユーザー（Nightbot などの bot）
+	GreetMaxPerWindow   int               `json:"GreetMaxPerWindow"`   // GreetWindowSec 秒あたりの最大挨拶数（レイド対策）
+	GreetWindowSec      int               `json:"GreetWindowSec"`
+	Commands            map[string]string `json:"Commands"` // "!discord" → 応答テンプレート
+
+	// TranslationTemplates は翻訳メッセージの書式（翻訳先の言語 → text/template、"*" は既定）。
+	// "by:JA" / "reply:JA" のように投稿方法（TRANSLATE_REPLY_MODE）ごとに分けられる
+	TranslationTemplates map[string]string `json:"TranslationTemplates,omitempty"`
+
+	// EventMessages は EventSub のイベント（"channel.follow" など）を受けたときの投稿文（空文字なら投稿しない）
+	EventMessages map[string]string `json:"EventMessages,omitempty"`
+}
+
+// botMessageStore は botMessage.json の読み書きを行います
+type botMessageStore struct {
+	mu   sync.Mutex
+	path string
+	data BotMessage
+
+	active map[string]*command.Command // レジストリに登録済みのカスタムコマンド
+}
+
+// loadBotMessage は botMessage.json を読み込みます。ファイルがなければ空の設定で開始します
+func loadBotMessage(path string) (*botMessageStore, error) {
+	s := &botMessageStore{path: path, active: map[string]*command.Command{}}
+	b, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(b, &s.data); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	if s.data.Commands == nil {
+		s.data.Commands = map[string]string{}
+	}
+	return s, nil
+}
+
+// save は現在の内容をファイルへ書き戻します（呼び出し側でロック済みであること）
+func (s *botMessageStore) save() error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // URL の & などをそのまま残す
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(s.data); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// commandKey は "discord" / "!Discord" などを "!discord" 形式にそろえます
+func commandKey(name string) string {
+	return command.Prefix + strings.ToLower(strings.TrimPrefix(name, command.Prefix))
+}
+
+// templateVars はカスタムコマンドの変数展開に使う値を返す関数群です
+type templateVars struct {
+	channel    string
+	streamInfo func() (*helix.Stream, error)
+	deeplUsage func() string
+}
+
+// expand はテンプレート中の {user} {channel} {game} {title} {uptime} {deepl_usage} を置換します
+func (v *templateVars) expand(tmpl string, c *command.Context) string {
+	user := c.Message.User.DisplayName
+	if user == "" {
+		user = c.Message.User.Name
+	}
+	game, title, uptime := "?", "?", "offline"
+	// 配信情報は必要なときだけ取得する（API呼び出しを減らすため）
+	if strings.Contains(tmpl, "{game}") || strings.Contains(tmpl, "{title}") || strings.Contains(tmpl, "{uptime}") {
+		if stream, err := v.streamInfo(); err == nil && stream != nil {
+			game, title = stream.GameName, stream.Title
+			if !stream.StartedAt.IsZero() {
+				uptime = formatUptime(time.Since(stream.StartedAt))
+			}
+		}
+	}
+	return strings.NewReplacer(
+		"{user}", user,
+		"{channel}", v.channel,
+		"{game}", game,
+		"{title}", title,
+		"{uptime}", uptime,
+		"{deepl_usage}", v.deeplUsage(),
+	).Replace(tmpl)
+}
+
+// formatUptime は配信時間を「1時間23分」形式にします
+func formatUptime(d time.Duration) string {
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	if h > 0 {
+		return fmt.Sprintf("%d時間%d分", h, m)
+	}
+	return fmt.Sprintf("%d分", m)
+}
+
+// customCommand はテンプレートで応答するカスタムコマンドを作ります
+func (s *botMessageStore) customCommand(key string, vars *templateVars) *command.Command {
+	return &command.Command{
+		Name:        strings.TrimPrefix(key, command.Prefix),
+		Description: "カスタム",
+		Usage:       key,
+		Cooldown:    10 * time.Second,
+		Handler: func(c *command.Context) string {
+			s.mu.Lock()
+			tmpl, ok := s.data.Commands[key]
+			s.mu.Unlock()
+			if !ok {
+				return ""
+			}
+			return vars.expand(tmpl, c)
+		},
+	}
+}
+
+// registerCustomCommands は botMessage.json のカスタムコマンドと、それを編集する !cmd を登録します
+func (s *botMessageStore) registerCustomCommands(reg *command.Registry, vars *templateVars) {
+	s.mu.Lock()
+	for key := range s.data.Commands {
+		cmd := s.customCommand(key, vars)
+		if err := reg.Add(cmd); err != nil {
+			// 組み込みコマンドと名前がかぶった場合は組み込みを優先
+			log.Printf("custom command %s skipped: %v", key, err)
+			continue
+		}
+		s.active[key] = cmd
+	}
+	s.mu.Unlock()
+
+	reg.Register(&command.Command{
+		Name:        "cmd",
+		Description: "カスタムコマンド編集",
+		Usage:       "!cmd add|edit|del !名前 [応答文]",
+		MinRole:     command.RoleModerator,
+		Handler: func(c *command.Context) string {
+			if len(c.Args) < 2 {
+				return "使い方: !cmd add|edit|del !名前 [応答文] (変数: {user} {channel} {game} {title} {uptime} {deepl_usage})"
+			}
+			op, key := strings.ToLower(c.Args[0]), commandKey(c.Args[1])
+			text := strings.Join(c.Args[2:], " ")
+
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			_, exists := s.data.Commands[key]
+
+			switch op {
+			case "add":
+				if exists || text == "" {
+					return fmt.Sprintf("❌ %s は追加できません（既存 or 応答文なし）", key)
+				}
+				cmd := s.customCommand(key, vars)
+				if err := reg.Add(cmd); err != nil {
+					return fmt.Sprintf("❌ %s は組み込みコマンドと重複しています", key)
+				}
+				s.active[key] = cmd
+				s.data.Commands[key] = text
+			case "edit":
+				if !exists || text == "" {
+					return fmt.Sprintf("❌ %s は編集できません（未登録 or 応答文なし）", key)
+				}
+				s.data.Commands[key] = text
+			case "del", "delete":
+				if !exists {
+					return fmt.Sprintf("❌ %s は登録されていません", key)
+				}
+				// 組み込みコマンドを消さないよう、自分で登録したものだけ解除する
+				if _, ok := s.active[key]; ok {
+					reg.Unregister(strings.TrimPrefix(key, command.Prefix))
+					delete(s.active, key)
+				}
+				delete(s.data.Commands, key)
+			default:
+				return "使い方: !cmd add|edit|del !名前 [応答文]"
+			}
+
+			if err := s.save(); err != nil {
+				return fmt.Sprintf("⚠ %s の保存に失敗しました: %v", s.path, err)
+			}
+			return fmt.Sprintf("✅ %s を %s しました", key, op)
+		},
+	})
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gempir/go-twitch-irc/v4"
+	"github.com/k-p5w/go-marybot/internal/twitchauth"
+)
+
+// chatAuth は bot アカウントのトークンを OAuth（authorization code フロー）で管理し、
+// 更新されたら IRC クライアントを新しいトークンでつなぎ直します
+type chatAuth struct {
+	auth         *twitchauth.UserAuth
+	loginURL     string        // 認証し直すときに開くURL（/auth/login）
+	tokens       chan string   // 新しいアクセストークン
+	reauthorized chan struct{} // 未接続のときにトークンが届いたことを知らせる
+}
+
+// newChatAuth は OAUTH_REDIRECT_URI が設定されていれば chatAuth を作成します（未設定なら nil）。
+// OAUTH_REDIRECT_URI があるのに CLIENT_ID / CLIENT_SECRET がない場合はエラーを返します。
+//   - TOKEN_FILE: 暗号化したトークンの保存先（既定 token.enc）
+//   - TOKEN_KEY_FILE: 暗号化の鍵（既定 token.key、なければ作成）
+//   - OAUTH_SCOPES: 要求するスコープ（既定 "chat:read chat:edit"）
+func newChatAuth(clientID, clientSecret, botName string) (*chatAuth, error) {
+	redirectURI := os.Getenv("OAUTH_REDIRECT_URI")
+	if redirectURI == "" {
+		return nil, nil
+	}
+	if clientID == "" || clientSecret == "" {
+		return nil, errors.New("OAUTH_REDIRECT_URI を使うには CLIENT_ID と CLIENT_SECRET の設定が必要です")
+	}
+	tokenFile := os.Getenv("TOKEN_FILE")
+	if tokenFile == "" {
+		tokenFile = "token.enc"
+	}
+	keyFile := os.Getenv("TOKEN_KEY_FILE")
+	if keyFile == "" {
+		keyFile = "token.key"
+	}
+	scopes := strings.Fields(os.Getenv("OAUTH_SCOPES"))
+	if len(scopes) == 0 {
+		scopes = []string{"chat:read", "chat:edit"}
+	}
+	auth, err := twitchauth.NewUserAuth(twitchauth.UserConfig{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURI:  redirectURI,
+		Scopes:       scopes,
+		Login:        botName,
+		TokenFile:    tokenFile,
+		KeyFile:      keyFile,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c := &chatAuth{
+		auth:         auth,
+		loginURL:     strings.TrimSuffix(redirectURI, "/callback") + "/login",
+		tokens:       make(chan string, 1),
+		reauthorized: make(chan struct{}, 1),
+	}
+	auth.OnUpdate = func(token string) {
+		// 古いトークンが残っていれば新しいものに差し替える
+		select {
+		case <-c.tokens:
+		default:
+		}
+		c.tokens <- token
+	}
+	return c, nil
+}
+
+// ircToken は IRC 用の "oauth:xxx" 形式のトークンを返します（未認証なら空文字）。起動時に使います
+func (c *chatAuth) ircToken() string {
+	token, err := c.auth.Token()
+	if err != nil {
+		log.Printf("chat auth: %v", err)
+		return ""
+	}
+	// 呼び出し側で直接使うので、リフレッシュで届いた通知は捨てる
+	select {
+	case <-c.tokens:
+	default:
+	}
+	return "oauth:" + token
+}
+
+// watch はトークンが更新されるたびに IRC クライアントへ反映します（goroutine で起動）。
+// 接続中なら切断して Connect のループで再接続させ、未接続なら待機中のループを起こします
+func (c *chatAuth) watch(client *twitch.Client) {
+	for token := range c.tokens {
+		client.SetIRCToken("oauth:" + token)
+		if err := client.Disconnect(); err != nil {
+			select {
+			case c.reauthorized <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// connectChat は IRC に接続し、切断されたら接続し直します。
+// トークンの更新による切断はすぐに、ログイン失敗は /auth/login で認証し直すまで待ってから再接続します
+func connectChat(client *twitch.Client, ca *chatAuth) error {
+	for {
+		err := client.Connect()
+		switch {
+		case errors.Is(err, twitch.ErrClientDisconnected):
+			log.Println("トークンを更新したため、チャットに再接続します。")
+		case errors.Is(err, twitch.ErrLoginAuthenticationFailed) && ca != nil:
+			// 期限前に失効した場合もあるので、まずリフレッシュを試す
+			if err := ca.auth.Refresh(); err != nil {
+				log.Printf("❌ チャットへのログインに失敗しました。%s で認証してください。(%v)", ca.loginURL, err)
+			}
+			<-ca.reauthorized
+		default:
+			return err
+		}
+	}
+}
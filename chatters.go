@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k-p5w/go-marybot/internal/command"
+)
+
+const defaultChatterFile = "chatters.json"
+
+// chatterRecord はユーザー1人分のチャット履歴です
+type chatterRecord struct {
+	Login        string    `json:"login"`
+	FirstSeen    time.Time `json:"firstSeen"`
+	LastSeen     time.Time `json:"lastSeen"`
+	MessageCount int       `json:"messageCount"`
+	Translate    string    `json:"translate,omitempty"` // 翻訳の希望（"off" / "always"、空なら通常どおり）
+}
+
+// 翻訳の希望（chatterRecord.Translate）
+const (
+	translateDefault = ""
+	translateOff     = "off"
+	translateAlways  = "always"
+)
+
+// chatterStore は Twitch ユーザーID をキーにチャット履歴をファイルへ保存します。
+// 再起動しても常連さんに [新] が付かないようにするためのものです。
+type chatterStore struct {
+	mu      sync.Mutex
+	path    string
+	users   map[string]*chatterRecord // ユーザーID → 履歴
+	session map[string]bool           // 今回の配信で発言済みのユーザーID
+	dirty   bool
+}
+
+// loadChatterStore はファイルから履歴を読み込みます。ファイルがなければ空で開始します
+func loadChatterStore(path string) (*chatterStore, error) {
+	s := &chatterStore{path: path, users: map[string]*chatterRecord{}, session: map[string]bool{}}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &s.users); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Seen は発言を記録し、「このチャンネルで初めて」「今回の配信で初めて」かどうかを返します
+func (s *chatterStore) Seen(userID, login string, now time.Time) (firstEver, firstStream bool) {
+	if userID == "" {
+		userID = login
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.users[userID]
+	if !ok {
+		rec = &chatterRecord{}
+		s.users[userID] = rec
+	}
+	// 翻訳の希望だけ先に登録された場合もあるので、発言数で初回を判定する
+	if rec.MessageCount == 0 {
+		rec.FirstSeen = now
+		firstEver = true
+	}
+	rec.Login = login // 名前変更に追従
+	rec.LastSeen = now
+	rec.MessageCount++
+	s.dirty = true
+
+	if !s.session[userID] {
+		s.session[userID] = true
+		firstStream = true
+	}
+	return firstEver, firstStream
+}
+
+// TranslatePref はユーザーの翻訳の希望を返します
+func (s *chatterStore) TranslatePref(userID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rec, ok := s.users[userID]; ok {
+		return rec.Translate
+	}
+	return translateDefault
+}
+
+// SetTranslatePref はユーザーの翻訳の希望を保存します（すぐにファイルへ書き込む）
+func (s *chatterStore) SetTranslatePref(userID, login, pref string) error {
+	s.mu.Lock()
+	rec, ok := s.users[userID]
+	if !ok {
+		rec = &chatterRecord{Login: login}
+		s.users[userID] = rec
+	}
+	rec.Translate = pref
+	s.dirty = true
+	s.mu.Unlock()
+	return s.Save()
+}
+
+// FindByLogin はログイン名からユーザーIDを探します（モデレーターが他の人の設定を変える場合に使う）
+func (s *chatterStore) FindByLogin(login string) (string, bool) {
+	login = strings.ToLower(strings.TrimPrefix(login, "@"))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, rec := range s.users {
+		if strings.ToLower(rec.Login) == login {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// registerTranslatePrefCommands は !notranslate / !translate を登録します。
+// モデレーター以上は "!notranslate @user" で他の人の設定も変更できます
+func registerTranslatePrefCommands(reg *command.Registry, s *chatterStore) {
+	handler := func(pref, done string) command.Handler {
+		return func(c *command.Context) string {
+			userID, login := c.Message.User.ID, c.Message.User.Name
+			args := c.Args
+			if len(args) > 0 && strings.HasPrefix(args[0], "@") {
+				if c.Role < command.RoleModerator {
+					log.Printf("translate pref: %s tried to change %s", login, args[0])
+					return ""
+				}
+				id, ok := s.FindByLogin(args[0])
+				if !ok {
+					return fmt.Sprintf("❓ %s さんはまだチャットしていません", args[0])
+				}
+				userID, login, args = id, strings.TrimPrefix(args[0], "@"), args[1:]
+			}
+			p, msg := pref, done
+			if len(args) > 0 && strings.EqualFold(args[0], "reset") {
+				p, msg = translateDefault, "通常に戻しました (reset)"
+			}
+			if err := s.SetTranslatePref(userID, login, p); err != nil {
+				log.Printf("translate pref save failed: %v", err)
+			}
+			return fmt.Sprintf("✅ @%s の翻訳設定: %s", login, msg)
+		}
+	}
+	reg.Register(&command.Command{
+		Name:        "notranslate",
+		Description: "自分の発言を翻訳しない",
+		Usage:       "!notranslate [@user] [reset]",
+		Handler:     handler(translateOff, "翻訳しません (off)"),
+	})
+	reg.Register(&command.Command{
+		Name:        "translate",
+		Description: "自分の発言を常に翻訳する",
+		Usage:       "!translate [@user] [reset]",
+		Handler:     handler(translateAlways, "常に翻訳します (always)"),
+	})
+}
+
+// ResetSession は「今回の配信で初めて」の判定をリセットします（配信開始時に呼ぶ）
+func (s *chatterStore) ResetSession() {
+	s.mu.Lock()
+	s.session = map[string]bool{}
+	s.mu.Unlock()
+}
+
+// Save は変更があればファイルへ書き込みます
+func (s *chatterStore) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+	b, err := json.MarshalIndent(s.users, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// autoSave は interval ごとに Save を呼び出します（goroutine で起動）
+func (s *chatterStore) autoSave(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := s.Save(); err != nil {
+			log.Printf("chatter store save failed: %v", err)
+		}
+	}
+}
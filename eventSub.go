@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/k-p5w/go-marybot/internal/eventsub"
+)
+
+// defaultEventMessages は botMessage.json の EventMessages が未設定のときの投稿文です。
+// {user} は相手の表示名、{viewers} はレイドの人数、{bits} はビッツ数、{tier} はサブスクのティアです
+var defaultEventMessages = map[string]string{
+	eventsub.TypeChannelFollow:    "💜 {user} さん、フォローありがとうございます！ (thanks for the follow!)",
+	eventsub.TypeChannelRaid:      "🎉 {user} さんから {viewers} 人のレイド！いらっしゃいませ！ (welcome raiders!)",
+	eventsub.TypeChannelSubscribe: "⭐ {user} さん、サブスクありがとうございます！ (thanks for subscribing!)",
+	eventsub.TypeChannelCheer:     "💎 {user} さん、{bits} ビッツありがとうございます！ (thanks for the bits!)",
+}
+
+// eventNotifier は EventSub のイベントをチャットへ投稿します
+type eventNotifier struct {
+	messages map[string]string
+	say      func(text string)
+}
+
+// post はイベントの種類に対応する投稿文を展開して投稿します（空文字に設定されていれば投稿しない）
+func (n *eventNotifier) post(subType string, vars ...string) {
+	tmpl, ok := n.messages[subType]
+	if !ok {
+		tmpl = defaultEventMessages[subType]
+	}
+	if tmpl == "" {
+		return
+	}
+	n.say(strings.NewReplacer(vars...).Replace(tmpl))
+}
+
+// registerEventHandlers は EventSub の各イベントに対する bot の動作を登録します。
+// 配信開始・終了は streamWatcher へ伝え、それ以外はお礼をチャットに投稿します
+func registerEventHandlers(es *eventsub.Client, n *eventNotifier, streams *streamWatcher) {
+	es.OnStreamOnline(func(e eventsub.StreamOnlineEvent) {
+		streams.Notify(StreamOnline)
+	})
+	es.OnStreamOffline(func(e eventsub.StreamOfflineEvent) {
+		streams.Notify(StreamOffline)
+	})
+	es.OnFollow(func(e eventsub.FollowEvent) {
+		n.post(eventsub.TypeChannelFollow, "{user}", e.UserName)
+	})
+	es.OnRaid(func(e eventsub.RaidEvent) {
+		n.post(eventsub.TypeChannelRaid, "{user}", e.FromBroadcasterUserName, "{viewers}", strconv.Itoa(e.Viewers))
+	})
+	es.OnSubscribe(func(e eventsub.SubscribeEvent) {
+		tier := strings.TrimSuffix(e.Tier, "000")
+		n.post(eventsub.TypeChannelSubscribe, "{user}", e.UserName, "{tier}", tier)
+	})
+	es.OnCheer(func(e eventsub.CheerEvent) {
+		user := e.UserName
+		if e.IsAnonymous || user == "" {
+			user = "Anonymous"
+		}
+		n.post(eventsub.TypeChannelCheer, "{user}", user, "{bits}", strconv.Itoa(e.Bits))
+	})
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/k-p5w/go-marybot/internal/command"
+	"github.com/k-p5w/go-marybot/internal/translate"
+)
+
+// registerGlossaryCommand は DeepL 用語集を編集する !glossary を登録します（モデレーター以上）
+func registerGlossaryCommand(reg *command.Registry, g *translate.Glossary, cache *translate.Cache) {
+	reg.Register(&command.Command{
+		Name:        "glossary",
+		Aliases:     []string{"gl"},
+		Description: "用語集の編集",
+		Usage:       "!glossary add 日本語 English | del 日本語 | sync",
+		MinRole:     command.RoleModerator,
+		Handler: func(c *command.Context) string {
+			if len(c.Args) == 0 {
+				return fmt.Sprintf("📚 用語集: %d語 | 使い方: !glossary add 日本語 English | del 日本語 | sync", g.Len())
+			}
+			var err error
+			switch strings.ToLower(c.Args[0]) {
+			case "add", "set":
+				if len(c.Args) < 3 {
+					return "使い方: !glossary add メイガス Magus"
+				}
+				err = g.Set(c.Args[1], strings.Join(c.Args[2:], " "))
+			case "del", "delete":
+				if len(c.Args) < 2 {
+					return "使い方: !glossary del メイガス"
+				}
+				err = g.Delete(c.Args[1])
+			case "sync":
+				err = g.Sync()
+			default:
+				return "使い方: !glossary add 日本語 English | del 日本語 | sync"
+			}
+			if err != nil {
+				log.Printf("glossary: %v", err)
+				return fmt.Sprintf("⚠ 用語集の更新に失敗しました: %v", err)
+			}
+			// 古い訳文がキャッシュから返らないようにする
+			cache.Clear()
+			return fmt.Sprintf("✅ 用語集を更新しました（%d語）", g.Len())
+		},
+	})
+}
@@ -6,6 +6,5 @@ require (
 	github.com/gempir/go-twitch-irc/v4 v4.3.1
 	github.com/go-resty/resty/v2 v2.17.1
 	github.com/joho/godotenv v1.5.1
+	golang.org/x/net v0.43.0
 )
-
-require golang.org/x/net v0.43.0 // indirect
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// greeter は初めてチャットしたユーザーへの挨拶を管理します。
+// レイド時などに挨拶が連投されないよう、一定時間あたりの回数を制限します。
+type greeter struct {
+	mu       sync.Mutex
+	ja, en   string          // 挨拶テンプレート（%v にユーザー名）
+	ignore   map[string]bool // 挨拶しないユーザー（小文字のログイン名）
+	max      int             // window あたりの最大挨拶数
+	window   time.Duration
+	recently []time.Time // window 内に挨拶した時刻
+}
+
+// newGreeter は botMessage.json の設定から greeter を作ります
+func newGreeter(m BotMessage, botName string) *greeter {
+	g := &greeter{
+		ja:     m.OnUserJoinMessage,
+		en:     m.OnUserJoinMessageEN,
+		ignore: map[string]bool{strings.ToLower(botName): true},
+		max:    m.GreetMaxPerWindow,
+		window: time.Duration(m.GreetWindowSec) * time.Second,
+	}
+	for _, name := range m.GreetIgnore {
+		g.ignore[strings.ToLower(name)] = true
+	}
+	if g.max <= 0 {
+		g.max = 3
+	}
+	if g.window <= 0 {
+		g.window = time.Minute
+	}
+	return g
+}
+
+// Greeting は挨拶文を返します。挨拶しない場合は空文字を返します
+//   - login: ログイン名（除外リストの判定用）
+//   - displayName: 挨拶に使う表示名
+//   - japanese: 最初の発言が日本語かどうか
+func (g *greeter) Greeting(login, displayName string, japanese bool) string {
+	tmpl := g.en
+	if japanese || tmpl == "" {
+		tmpl = g.ja
+	}
+	if tmpl == "" || g.ignore[strings.ToLower(login)] {
+		return ""
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	kept := g.recently[:0]
+	for _, t := range g.recently {
+		if now.Sub(t) < g.window {
+			kept = append(kept, t)
+		}
+	}
+	g.recently = kept
+	if len(g.recently) >= g.max {
+		return ""
+	}
+	g.recently = append(g.recently, now)
+	return strings.ReplaceAll(tmpl, "%v", displayName)
+}
@@ -0,0 +1,23 @@
+package bandainamco
+
+import (
+	"time"
+
+	"github.com/k-p5w/go-marybot/internal/command"
+)
+
+// チャットコマンドの登録（main.go から import するだけで有効になる）
+func init() {
+	command.Register(&command.Command{
+		Name:        "syn",
+		Description: "シンデュアのMAP状況",
+		Usage:       "!syn",
+		// 連打でチャットが埋まらないように制限（COMMAND_COOLDOWNS で上書き可）
+		Cooldown:     30 * time.Second,
+		UserCooldown: 60 * time.Second,
+		Handler: func(c *command.Context) string {
+			// どの配信中であっても、!syn と打たれれば即座に回答
+			return GetSynStatus(true) // 「今の状況」を返す関数
+		},
+	})
+}
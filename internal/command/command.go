@@ -0,0 +1,307 @@
+package command
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Prefix はチャットコマンドの接頭辞です
+const Prefix = "!"
+
+// Context はコマンド実行時に渡される情報です
+type Context struct {
+	Name    string                // 実際に打たれたコマンド名（エイリアスの場合もある）
+	Args    []string              // コマンド名以降の引数
+	Channel string                // 発言されたチャンネル
+	Role    Role                  // 発言者の権限レベル
+	Message twitch.PrivateMessage // 元のチャットメッセージ
+}
+
+// Handler はコマンドの処理本体です。戻り値がチャットに投稿されます（空文字なら投稿しない）
+type Handler func(c *Context) string
+
+// Command はチャットコマンド1つ分の定義です
+type Command struct {
+	Name        string   // コマンド名（"!" なし、小文字）
+	Aliases     []string // 別名
+	Description string   // !help に表示する説明
+	Usage       string   // 使い方（例: "!syn"）
+	Handler     Handler
+	MinRole     Role // 実行に必要な最低権限（未指定なら全員）
+
+	Cooldown     time.Duration // チャンネル全体でのクールダウン（0なら無制限）
+	UserCooldown time.Duration // ユーザーごとのクールダウン（0なら無制限）
+}
+
+// Registry はコマンドの登録先です
+type Registry struct {
+	mu       sync.RWMutex
+	commands map[string]*Command // 正式名 → コマンド
+	aliases  map[string]string   // 別名/正式名 → 正式名
+
+	// Notify が設定されていると、クールダウン中の呼び出しに「あとN秒」を1度だけ通知します。
+	// nil の場合は黙って無視します。
+	Notify func(message twitch.PrivateMessage, text string)
+
+	lastGlobal map[string]time.Time // コマンド名 → 最終実行時刻
+	lastUser   map[string]time.Time // コマンド名+ユーザーID → 最終実行時刻
+	notified   map[string]time.Time // 通知済みのクールダウン（キー → 解除時刻）
+}
+
+// NewRegistry は空のレジストリを作成します
+func NewRegistry() *Registry {
+	return &Registry{
+		commands:   map[string]*Command{},
+		aliases:    map[string]string{},
+		lastGlobal: map[string]time.Time{},
+		lastUser:   map[string]time.Time{},
+		notified:   map[string]time.Time{},
+	}
+}
+
+// Default は各パッケージが init() で登録する共通レジストリです
+var Default = NewRegistry()
+
+// Register は Default にコマンドを登録します
+func Register(cmd *Command) {
+	Default.Register(cmd)
+}
+
+// Register はコマンドを登録します。名前や別名が重複した場合は panic します（登録は起動時のみ想定）
+func (r *Registry) Register(cmd *Command) {
+	if err := r.Add(cmd); err != nil {
+		panic(err)
+	}
+}
+
+// Add はコマンドを登録します。実行時に追加する場合はこちらを使い、重複はエラーで返します
+func (r *Registry) Add(cmd *Command) error {
+	if cmd == nil || cmd.Name == "" || cmd.Handler == nil {
+		return fmt.Errorf("command: invalid command")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := append([]string{cmd.Name}, cmd.Aliases...)
+	for _, n := range names {
+		if _, dup := r.aliases[strings.ToLower(n)]; dup {
+			return fmt.Errorf("command: duplicate name %s", n)
+		}
+	}
+	r.commands[strings.ToLower(cmd.Name)] = cmd
+	for _, n := range names {
+		r.aliases[strings.ToLower(n)] = strings.ToLower(cmd.Name)
+	}
+	return nil
+}
+
+// Unregister はコマンドを登録解除します（実行時に追加されたカスタムコマンド用）
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.aliases[strings.ToLower(name)]
+	if !ok {
+		return false
+	}
+	cmd := r.commands[key]
+	delete(r.commands, key)
+	for _, n := range append([]string{cmd.Name}, cmd.Aliases...) {
+		delete(r.aliases, strings.ToLower(n))
+	}
+	return true
+}
+
+// Lookup は名前または別名からコマンドを探します
+func (r *Registry) Lookup(name string) (*Command, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	key, ok := r.aliases[strings.ToLower(name)]
+	if !ok {
+		return nil, false
+	}
+	return r.commands[key], true
+}
+
+// Commands は登録済みコマンドを名前順で返します
+func (r *Registry) Commands() []*Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]*Command, 0, len(r.commands))
+	for _, c := range r.commands {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Parse はチャット本文をコマンド名と引数に分解します。コマンドでなければ ok=false
+func Parse(text string) (name string, args []string, ok bool) {
+	if !strings.HasPrefix(text, Prefix) {
+		return "", nil, false
+	}
+	fields := strings.Fields(strings.TrimPrefix(text, Prefix))
+	if len(fields) == 0 {
+		// "!" だけの場合は help 扱い
+		return "!", nil, true
+	}
+	return strings.ToLower(fields[0]), fields[1:], true
+}
+
+// Dispatch はメッセージに該当するコマンドを実行します。
+// 登録済みコマンドでなければ handled=false を返します（翻訳処理へ回すため）
+func (r *Registry) Dispatch(message twitch.PrivateMessage) (reply string, handled bool) {
+	name, args, ok := Parse(message.Message)
+	if !ok {
+		return "", false
+	}
+	cmd, ok := r.Lookup(name)
+	if !ok {
+		return "", false
+	}
+	role := RoleOf(message.User)
+	if role < cmd.MinRole {
+		// 権限不足はログだけ残して無視する
+		log.Printf("command: denied %s%s for %s (role=%s, need=%s)", Prefix, cmd.Name, message.User.Name, role, cmd.MinRole)
+		return "", true
+	}
+	if wait, key := r.cooldown(cmd, message, time.Now()); wait > 0 {
+		r.notifyCooldown(key, wait, message)
+		return "", true
+	}
+	ctx := &Context{Name: name, Args: args, Channel: message.Channel, Role: role, Message: message}
+	return cmd.Handler(ctx), true
+}
+
+// cooldown はクールダウン中なら残り時間を返します。実行可能な場合は実行時刻を記録します
+func (r *Registry) cooldown(cmd *Command, message twitch.PrivateMessage, now time.Time) (time.Duration, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := strings.ToLower(cmd.Name)
+	userKey := name + "\x00" + message.User.ID
+	if cmd.Cooldown > 0 {
+		if wait := cmd.Cooldown - now.Sub(r.lastGlobal[name]); wait > 0 {
+			return wait, userKey
+		}
+	}
+	if cmd.UserCooldown > 0 {
+		if wait := cmd.UserCooldown - now.Sub(r.lastUser[userKey]); wait > 0 {
+			return wait, userKey
+		}
+	}
+	r.lastGlobal[name] = now
+	r.lastUser[userKey] = now
+	return 0, userKey
+}
+
+// notifyCooldown は同じクールダウン期間中に1度だけ「あとN秒」を通知します
+func (r *Registry) notifyCooldown(key string, wait time.Duration, message twitch.PrivateMessage) {
+	if r.Notify == nil {
+		return
+	}
+	now := time.Now()
+	r.mu.Lock()
+	if until, ok := r.notified[key]; ok && now.Before(until) {
+		r.mu.Unlock()
+		return
+	}
+	r.notified[key] = now.Add(wait)
+	r.mu.Unlock()
+
+	user := message.User.DisplayName
+	if user == "" {
+		user = message.User.Name
+	}
+	sec := int(math.Ceil(wait.Seconds()))
+	r.Notify(message, fmt.Sprintf("⏳ @%s あと%d秒待ってね (wait %ds)", user, sec, sec))
+}
+
+// SetCooldowns は "syn=30/10,status=60" 形式の設定でクールダウンを上書きします。
+// "全体秒数/ユーザー秒数" の順で、ユーザー秒数は省略できます
+func (r *Registry) SetCooldowns(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("cooldown: invalid entry %q", item)
+		}
+		cmd, found := r.Lookup(strings.TrimPrefix(strings.TrimSpace(name), Prefix))
+		if !found {
+			log.Printf("cooldown: unknown command %q (skipped)", name)
+			continue
+		}
+		globalStr, userStr, _ := strings.Cut(value, "/")
+		global, err := parseSeconds(globalStr)
+		if err != nil {
+			return fmt.Errorf("cooldown: %s: %v", name, err)
+		}
+		user, err := parseSeconds(userStr)
+		if err != nil {
+			return fmt.Errorf("cooldown: %s: %v", name, err)
+		}
+		r.mu.Lock()
+		cmd.Cooldown, cmd.UserCooldown = global, user
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+// parseSeconds は秒数の文字列を Duration に変換します（空文字は0）
+func parseSeconds(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid seconds %q", s)
+	}
+	return time.Duration(n) * time.Second, nil
+}
+
+// HelpCommand はレジストリの内容から !help を生成するコマンドを返します
+func (r *Registry) HelpCommand() *Command {
+	return &Command{
+		Name:        "help",
+		Aliases:     []string{"!"},
+		Description: "コマンド一覧",
+		Usage:       "!help [コマンド名]",
+		Handler: func(c *Context) string {
+			if len(c.Args) > 0 {
+				cmd, ok := r.Lookup(strings.TrimPrefix(c.Args[0], Prefix))
+				if !ok {
+					return fmt.Sprintf("❓ 不明なコマンド: %s", c.Args[0])
+				}
+				help := fmt.Sprintf("📖 %s%s: %s | 使い方: %s", Prefix, cmd.Name, cmd.Description, cmd.Usage)
+				if cmd.MinRole > RoleEveryone {
+					help += " | 権限: " + cmd.MinRole.String()
+				}
+				return help
+			}
+			return r.HelpText(c.Role)
+		},
+	}
+}
+
+// HelpText は指定した権限で利用可能なコマンドの一覧を1行で返します
+func (r *Registry) HelpText(role Role) string {
+	var items []string
+	for _, c := range r.Commands() {
+		if c.Name == "help" || c.MinRole > role {
+			continue
+		}
+		items = append(items, fmt.Sprintf("%s%s (%s)", Prefix, c.Name, c.Description))
+	}
+	return "📖 利用可能コマンド: " + strings.Join(items, ", ")
+}
@@ -0,0 +1,95 @@
+package command
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gempir/go-twitch-irc/v4"
+)
+
+// Role はコマンドを実行できる権限レベルです（大きいほど強い）
+type Role int
+
+const (
+	RoleEveryone Role = iota
+	RoleSubscriber
+	RoleVIP
+	RoleModerator
+	RoleBroadcaster
+)
+
+// String はログや !help 用の表示名を返します
+func (r Role) String() string {
+	switch r {
+	case RoleSubscriber:
+		return "subscriber"
+	case RoleVIP:
+		return "vip"
+	case RoleModerator:
+		return "moderator"
+	case RoleBroadcaster:
+		return "broadcaster"
+	default:
+		return "everyone"
+	}
+}
+
+// ParseRole は "mod" や "broadcaster" などの文字列を Role に変換します
+func ParseRole(s string) (Role, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "everyone", "all":
+		return RoleEveryone, true
+	case "sub", "subscriber":
+		return RoleSubscriber, true
+	case "vip":
+		return RoleVIP, true
+	case "mod", "moderator":
+		return RoleModerator, true
+	case "broadcaster", "owner":
+		return RoleBroadcaster, true
+	}
+	return RoleEveryone, false
+}
+
+// RoleOf はバッジ情報からユーザーの権限レベルを判定します
+func RoleOf(user twitch.User) Role {
+	switch {
+	case user.IsBroadcaster || user.Badges["broadcaster"] > 0:
+		return RoleBroadcaster
+	case user.IsMod || user.Badges["moderator"] > 0:
+		return RoleModerator
+	case user.IsVip || user.Badges["vip"] > 0:
+		return RoleVIP
+	case user.Badges["subscriber"] > 0 || user.Badges["founder"] > 0:
+		return RoleSubscriber
+	}
+	return RoleEveryone
+}
+
+// SetRoles は "status=mod,syn=everyone" 形式の設定で必要権限を上書きします
+func (r *Registry) SetRoles(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("role: invalid entry %q", item)
+		}
+		role, ok := ParseRole(value)
+		if !ok {
+			return fmt.Errorf("role: %s: unknown role %q", name, value)
+		}
+		cmd, found := r.Lookup(strings.TrimPrefix(strings.TrimSpace(name), Prefix))
+		if !found {
+			log.Printf("role: unknown command %q (skipped)", name)
+			continue
+		}
+		r.mu.Lock()
+		cmd.MinRole = role
+		r.mu.Unlock()
+	}
+	return nil
+}
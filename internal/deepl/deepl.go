@@ -0,0 +1,203 @@
+package deepl
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// DeepL API のエンドポイント
+const (
+	FreeURL = "https://api-free.deepl.com"
+	ProURL  = "https://api.deepl.com"
+)
+
+// StatusQuotaExceeded は DeepL が残量切れのときに返す独自のステータスコードです
+const StatusQuotaExceeded = 456
+
+var (
+	// ErrAuth は APIキーが無効など、認証に失敗したことを示します（401/403）
+	ErrAuth = errors.New("deepl: authorization failed")
+	// ErrQuotaExceeded は今月の文字数を使い切ったことを示します（456）
+	ErrQuotaExceeded = errors.New("deepl: quota exceeded")
+	// ErrRateLimited はリトライしてもリクエスト過多が解消しなかったことを示します（429）
+	ErrRateLimited = errors.New("deepl: too many requests")
+)
+
+// BaseURL は DeepL API のベースURLを決めます。
+//   - override: 指定があれば最優先（テスト用のモックサーバーなど）
+//   - plan: "free" / "pro" を明示した場合はそれに従う
+//   - どちらもなければキーの末尾が ":fx" ならフリープラン、それ以外は Pro
+func BaseURL(apiKey, plan, override string) string {
+	if override != "" {
+		return strings.TrimRight(override, "/")
+	}
+	switch strings.ToLower(plan) {
+	case "free":
+		return FreeURL
+	case "pro":
+		return ProURL
+	}
+	if strings.HasSuffix(apiKey, ":fx") {
+		return FreeURL
+	}
+	return ProURL
+}
+
+// Client は DeepL API のクライアントです。429 と 5xx は指数バックオフでリトライします
+type Client struct {
+	apiKey  string
+	baseURL string
+	http    *resty.Client
+}
+
+// New は DeepL クライアントを作成します。baseURL は BaseURL で決めたものを渡します
+func New(apiKey, baseURL string) *Client {
+	r := resty.New().
+		SetBaseURL(baseURL).
+		SetHeader("Authorization", "DeepL-Auth-Key "+apiKey).
+		SetTimeout(15 * time.Second).
+		SetRetryCount(3).
+		SetRetryWaitTime(500 * time.Millisecond).
+		SetRetryMaxWaitTime(5 * time.Second).
+		AddRetryCondition(func(resp *resty.Response, err error) bool {
+			if err != nil {
+				return true
+			}
+			code := resp.StatusCode()
+			return code == http.StatusTooManyRequests || code >= 500
+		})
+	return &Client{apiKey: apiKey, baseURL: baseURL, http: r}
+}
+
+// BaseURL は接続先のベースURLを返します
+func (c *Client) BaseURL() string { return c.baseURL }
+
+// TranslateRequest は /v2/translate のリクエストです
+type TranslateRequest struct {
+	Text        string
+	TargetLang  string
+	SourceLang  string // 省略時は自動判定（GlossaryID を使う場合は必須）
+	GlossaryID  string
+	TagHandling string // "xml" / "html"
+}
+
+// Translation は翻訳結果1件です
+type Translation struct {
+	DetectedSourceLanguage string `json:"detected_source_language"`
+	Text                   string `json:"text"`
+}
+
+type translateResponse struct {
+	Translations []Translation `json:"translations"`
+}
+
+// Usage は今月の使用状況です
+type Usage struct {
+	CharacterCount int `json:"character_count"`
+	CharacterLimit int `json:"character_limit"`
+}
+
+// Glossary は DeepL 上の用語集です
+type Glossary struct {
+	GlossaryID string `json:"glossary_id"`
+	Name       string `json:"name"`
+	SourceLang string `json:"source_lang"`
+	TargetLang string `json:"target_lang"`
+	EntryCount int    `json:"entry_count"`
+}
+
+type errorResponse struct {
+	Message string `json:"message"`
+}
+
+// Translate はテキストを翻訳します
+func (c *Client) Translate(req TranslateRequest) (*Translation, error) {
+	form := map[string]string{"text": req.Text, "target_lang": req.TargetLang}
+	if req.SourceLang != "" {
+		form["source_lang"] = req.SourceLang
+	}
+	if req.GlossaryID != "" {
+		form["glossary_id"] = req.GlossaryID
+	}
+	if req.TagHandling != "" {
+		form["tag_handling"] = req.TagHandling
+	}
+	var out translateResponse
+	if err := c.do(c.http.R().SetFormData(form).SetResult(&out), http.MethodPost, "/v2/translate"); err != nil {
+		return nil, err
+	}
+	if len(out.Translations) == 0 {
+		return nil, fmt.Errorf("deepl: empty translation response")
+	}
+	return &out.Translations[0], nil
+}
+
+// Usage は今月の使用状況を取得します
+func (c *Client) Usage() (*Usage, error) {
+	var out Usage
+	if err := c.do(c.http.R().SetResult(&out), http.MethodGet, "/v2/usage"); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListGlossaries は用語集の一覧を取得します
+func (c *Client) ListGlossaries() ([]Glossary, error) {
+	var out struct {
+		Glossaries []Glossary `json:"glossaries"`
+	}
+	if err := c.do(c.http.R().SetResult(&out), http.MethodGet, "/v2/glossaries"); err != nil {
+		return nil, err
+	}
+	return out.Glossaries, nil
+}
+
+// CreateGlossary は用語集を作成します。entries は "見出し\t訳語" の行です
+func (c *Client) CreateGlossary(name, sourceLang, targetLang string, entries []string) (*Glossary, error) {
+	var out Glossary
+	req := c.http.R().SetResult(&out).SetBody(map[string]string{
+		"name":           name,
+		"source_lang":    sourceLang,
+		"target_lang":    targetLang,
+		"entries":        strings.Join(entries, "\n"),
+		"entries_format": "tsv",
+	})
+	if err := c.do(req, http.MethodPost, "/v2/glossaries"); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteGlossary は用語集を削除します
+func (c *Client) DeleteGlossary(id string) error {
+	return c.do(c.http.R(), http.MethodDelete, "/v2/glossaries/"+id)
+}
+
+// do はリクエストを送り、ステータスコードを種類ごとのエラーに変換します
+func (c *Client) do(req *resty.Request, method, path string) error {
+	var apiErr errorResponse
+	resp, err := req.ForceContentType("application/json").SetError(&apiErr).Execute(method, path)
+	if err != nil {
+		return fmt.Errorf("deepl: %s %s: %w", method, path, err)
+	}
+	if !resp.IsError() {
+		return nil
+	}
+	var kind error
+	switch resp.StatusCode() {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		kind = ErrAuth
+	case StatusQuotaExceeded:
+		kind = ErrQuotaExceeded
+	case http.StatusTooManyRequests:
+		kind = ErrRateLimited
+	default:
+		return fmt.Errorf("deepl: %s %s: %s %s", method, path, resp.Status(), apiErr.Message)
+	}
+	return fmt.Errorf("%w (%s %s: %s)", kind, method, path, resp.Status())
+}
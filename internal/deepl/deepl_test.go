@@ -0,0 +1,134 @@
+package deepl
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient は handler をモックサーバーにした Client を返します。
+// リトライの待ち時間はテスト用に短くします
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c := New("key:fx", BaseURL("key:fx", "", srv.URL))
+	c.http.SetRetryWaitTime(time.Millisecond).SetRetryMaxWaitTime(5 * time.Millisecond)
+	return c
+}
+
+// statusHandler は常に status を返し、呼ばれた回数を calls に数えます
+func statusHandler(status int, calls *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{"message":"error"}`)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.URL.Path != "/v2/translate" || r.Header.Get("Authorization") != "DeepL-Auth-Key key:fx" {
+			t.Errorf("request = %s %s", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		if r.Form.Get("text") != "こんにちは" || r.Form.Get("target_lang") != "EN" || r.Form.Get("glossary_id") != "g1" {
+			t.Errorf("form = %v", r.Form)
+		}
+		fmt.Fprint(w, `{"translations":[{"detected_source_language":"JA","text":"Hello"}]}`)
+	})
+	res, err := c.Translate(TranslateRequest{Text: "こんにちは", TargetLang: "EN", SourceLang: "JA", GlossaryID: "g1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Text != "Hello" || res.DetectedSourceLanguage != "JA" {
+		t.Errorf("Translate() = %+v", res)
+	}
+}
+
+func TestStatusErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		want      error
+		wantCalls int32
+	}{
+		{"無効なキー", http.StatusForbidden, ErrAuth, 1},
+		{"キーなし", http.StatusUnauthorized, ErrAuth, 1},
+		{"残量切れはリトライしない", StatusQuotaExceeded, ErrQuotaExceeded, 1},
+		{"429 はリトライしてからあきらめる", http.StatusTooManyRequests, ErrRateLimited, 4},
+	}
+	for _, tt := range tests {
+		var calls int32
+		c := newTestClient(t, statusHandler(tt.status, &calls))
+		_, err := c.Translate(TranslateRequest{Text: "hi", TargetLang: "JA"})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+		if calls != tt.wantCalls {
+			t.Errorf("%s: calls = %d, want %d", tt.name, calls, tt.wantCalls)
+		}
+	}
+}
+
+func TestRetryServerError(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"character_count":1200,"character_limit":500000}`)
+	})
+	u, err := c.Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.CharacterCount != 1200 || u.CharacterLimit != 500000 || calls != 3 {
+		t.Errorf("Usage() = %+v after %d calls", u, calls)
+	}
+}
+
+func TestServerErrorGivesUp(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, statusHandler(http.StatusInternalServerError, &calls))
+	_, err := c.Usage()
+	if err == nil || errors.Is(err, ErrAuth) || errors.Is(err, ErrQuotaExceeded) || errors.Is(err, ErrRateLimited) {
+		t.Errorf("err = %v, want a plain error", err)
+	}
+	if calls != 4 {
+		t.Errorf("calls = %d, want 4", calls)
+	}
+}
+
+func TestBadJSON(t *testing.T) {
+	for _, body := range []string{`{"translations":`, `{"translations":[]}`, `not json`} {
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, body)
+		})
+		if res, err := c.Translate(TranslateRequest{Text: "hi", TargetLang: "JA"}); err == nil {
+			t.Errorf("body %q: Translate() = %+v, want error", body, res)
+		}
+	}
+}
+
+func TestBaseURL(t *testing.T) {
+	tests := []struct {
+		key, plan, override, want string
+	}{
+		{"abc:fx", "", "", FreeURL},
+		{"abc", "", "", ProURL},
+		{"abc", "free", "", FreeURL},
+		{"abc:fx", "pro", "", ProURL},
+		{"abc", "pro", "http://127.0.0.1:3000/", "http://127.0.0.1:3000"},
+	}
+	for _, tt := range tests {
+		if got := BaseURL(tt.key, tt.plan, tt.override); got != tt.want {
+			t.Errorf("BaseURL(%q, %q, %q) = %q, want %q", tt.key, tt.plan, tt.override, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package eventsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"golang.org/x/net/websocket"
+)
+
+// 本番の接続先。Twitch CLI のモック（twitch event websocket start-server）で試す場合は
+// WebSocketURL="ws://127.0.0.1:8080/ws"、HelixURL="http://127.0.0.1:8080" のように差し替えます
+const (
+	DefaultWebSocketURL = "wss://eventsub.wss.twitch.tv/ws"
+	DefaultHelixURL     = "https://api.twitch.tv/helix"
+)
+
+// Notification は受信したイベント1件です。Event は種類ごとの JSON のまま渡されます
+type Notification struct {
+	MessageID string
+	Type      string // "stream.online" など
+	Version   string
+	Timestamp time.Time
+	Event     json.RawMessage
+}
+
+// Client は EventSub の WebSocket に接続し、受信したイベントを登録済みのハンドラへ渡します。
+// 接続が切れた場合は自動的に接続し直し、サブスクリプションも作り直します
+type Client struct {
+	WebSocketURL  string
+	HelixURL      string
+	ClientID      string
+	Token         func() (string, error) // ユーザーアクセストークン（"oauth:" なし）を返す
+	Subscriptions []Subscription
+
+	mu       sync.RWMutex
+	handlers map[string][]func(Notification)
+	seen     map[string]time.Time // 重複配信を捨てるための message_id
+	http     *resty.Client
+}
+
+// NewClient は本番の接続先を使うクライアントを作成します
+func NewClient(clientID string, token func() (string, error), subs []Subscription) *Client {
+	return &Client{
+		WebSocketURL:  DefaultWebSocketURL,
+		HelixURL:      DefaultHelixURL,
+		ClientID:      clientID,
+		Token:         token,
+		Subscriptions: subs,
+		handlers:      map[string][]func(Notification){},
+		seen:          map[string]time.Time{},
+		http:          resty.New().SetTimeout(15 * time.Second),
+	}
+}
+
+// Handle はイベントの種類ごとにハンドラを登録します（Run より前に登録すること）
+func (c *Client) Handle(subType string, h func(Notification)) {
+	c.mu.Lock()
+	c.handlers[subType] = append(c.handlers[subType], h)
+	c.mu.Unlock()
+}
+
+// on は JSON を型付きの構造体に変換してから渡すハンドラを登録します
+func on[T any](c *Client, subType string, h func(T)) {
+	c.Handle(subType, func(n Notification) {
+		var ev T
+		if err := json.Unmarshal(n.Event, &ev); err != nil {
+			log.Printf("eventsub: %s: %v", subType, err)
+			return
+		}
+		h(ev)
+	})
+}
+
+// OnStreamOnline は配信開始時の処理を登録します
+func (c *Client) OnStreamOnline(h func(StreamOnlineEvent)) { on(c, TypeStreamOnline, h) }
+
+// OnStreamOffline は配信終了時の処理を登録します
+func (c *Client) OnStreamOffline(h func(StreamOfflineEvent)) { on(c, TypeStreamOffline, h) }
+
+// OnFollow はフォロー時の処理を登録します
+func (c *Client) OnFollow(h func(FollowEvent)) { on(c, TypeChannelFollow, h) }
+
+// OnRaid はレイドを受けたときの処理を登録します
+func (c *Client) OnRaid(h func(RaidEvent)) { on(c, TypeChannelRaid, h) }
+
+// OnSubscribe はサブスク時の処理を登録します
+func (c *Client) OnSubscribe(h func(SubscribeEvent)) { on(c, TypeChannelSubscribe, h) }
+
+// OnCheer はビッツ応援時の処理を登録します
+func (c *Client) OnCheer(h func(CheerEvent)) { on(c, TypeChannelCheer, h) }
+
+// message は WebSocket で届くメッセージの共通部分です
+type message struct {
+	Metadata struct {
+		MessageID           string    `json:"message_id"`
+		MessageType         string    `json:"message_type"`
+		MessageTimestamp    time.Time `json:"message_timestamp"`
+		SubscriptionType    string    `json:"subscription_type"`
+		SubscriptionVersion string    `json:"subscription_version"`
+	} `json:"metadata"`
+	Payload struct {
+		Session *struct {
+			ID                      string `json:"id"`
+			Status                  string `json:"status"`
+			KeepaliveTimeoutSeconds int    `json:"keepalive_timeout_seconds"`
+			ReconnectURL            string `json:"reconnect_url"`
+		} `json:"session"`
+		Subscription *struct {
+			ID      string `json:"id"`
+			Type    string `json:"type"`
+			Version string `json:"version"`
+			Status  string `json:"status"`
+		} `json:"subscription"`
+		Event json.RawMessage `json:"event"`
+	} `json:"payload"`
+}
+
+// Run は ctx が終了するまで接続を維持します。切断されたら待ち時間を延ばしながら接続し直します
+func (c *Client) Run(ctx context.Context) error {
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := c.session(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// しばらく接続できていたなら待ち時間を戻す
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		log.Printf("eventsub: disconnected: %v (retry in %s)", err, backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, time.Minute)
+	}
+}
+
+// session は1回分の接続を処理します。welcome を受けたらサブスクリプションを作成し、
+// session_reconnect を受けたら新しいURLへ乗り換えます（この場合サブスクリプションは引き継がれる）
+func (c *Client) session(ctx context.Context) error {
+	conn, err := c.dial(ctx, c.WebSocketURL)
+	if err != nil {
+		return err
+	}
+	var connMu sync.Mutex
+	closeConn := func() {
+		connMu.Lock()
+		conn.Close()
+		connMu.Unlock()
+	}
+	defer closeConn()
+	stop := context.AfterFunc(ctx, closeConn)
+	defer stop()
+
+	keepalive := 10 * time.Second
+	subscribed := false
+	for {
+		msg, err := receive(conn, keepalive)
+		if err != nil {
+			return err
+		}
+		switch msg.Metadata.MessageType {
+		case "session_welcome":
+			if msg.Payload.Session == nil {
+				return fmt.Errorf("eventsub: welcome without session")
+			}
+			keepalive = keepaliveTimeout(msg.Payload.Session.KeepaliveTimeoutSeconds)
+			if !subscribed {
+				if err := c.subscribe(msg.Payload.Session.ID); err != nil {
+					return err
+				}
+				subscribed = true
+			}
+			log.Printf("eventsub: connected (session %s)", msg.Payload.Session.ID)
+		case "session_keepalive":
+			// 読み込みの期限を延ばすだけ
+		case "notification":
+			c.dispatch(msg)
+		case "session_reconnect":
+			if msg.Payload.Session == nil || msg.Payload.Session.ReconnectURL == "" {
+				return fmt.Errorf("eventsub: reconnect without url")
+			}
+			// 新しい接続で welcome を受け取ってから古い接続を閉じる
+			next, err := c.dial(ctx, msg.Payload.Session.ReconnectURL)
+			if err != nil {
+				return err
+			}
+			welcome, err := receive(next, keepalive)
+			if err != nil || welcome.Metadata.MessageType != "session_welcome" || welcome.Payload.Session == nil {
+				next.Close()
+				return fmt.Errorf("eventsub: reconnect failed: %v", err)
+			}
+			keepalive = keepaliveTimeout(welcome.Payload.Session.KeepaliveTimeoutSeconds)
+			connMu.Lock()
+			conn.Close()
+			conn = next
+			connMu.Unlock()
+			log.Printf("eventsub: reconnected (session %s)", welcome.Payload.Session.ID)
+		case "revocation":
+			if s := msg.Payload.Subscription; s != nil {
+				log.Printf("eventsub: subscription %s revoked: %s", s.Type, s.Status)
+			}
+		default:
+			log.Printf("eventsub: unknown message type %q", msg.Metadata.MessageType)
+		}
+	}
+}
+
+// keepaliveTimeout は welcome で指定された秒数を Duration にします
+func keepaliveTimeout(sec int) time.Duration {
+	if sec <= 0 {
+		sec = 10
+	}
+	return time.Duration(sec) * time.Second
+}
+
+// dial は WebSocket に接続します
+func (c *Client) dial(ctx context.Context, url string) (*websocket.Conn, error) {
+	cfg, err := websocket.NewConfig(url, "http://localhost/")
+	if err != nil {
+		return nil, err
+	}
+	return cfg.DialContext(ctx)
+}
+
+// receive はメッセージを1件読み込みます。keepalive を過ぎても何も届かなければ切断扱いにします
+func receive(conn *websocket.Conn, keepalive time.Duration) (*message, error) {
+	conn.SetReadDeadline(time.Now().Add(keepalive + 5*time.Second))
+	var raw []byte
+	if err := websocket.Message.Receive(conn, &raw); err != nil {
+		return nil, err
+	}
+	var msg message
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, fmt.Errorf("eventsub: %v", err)
+	}
+	return &msg, nil
+}
+
+// dispatch は通知を登録済みのハンドラへ渡します（同じ message_id は1度だけ）
+func (c *Client) dispatch(msg *message) {
+	now := time.Now()
+	c.mu.Lock()
+	if _, dup := c.seen[msg.Metadata.MessageID]; dup {
+		c.mu.Unlock()
+		return
+	}
+	for id, t := range c.seen {
+		if now.Sub(t) > 10*time.Minute {
+			delete(c.seen, id)
+		}
+	}
+	c.seen[msg.Metadata.MessageID] = now
+	handlers := c.handlers[msg.Metadata.SubscriptionType]
+	c.mu.Unlock()
+
+	n := Notification{
+		MessageID: msg.Metadata.MessageID,
+		Type:      msg.Metadata.SubscriptionType,
+		Version:   msg.Metadata.SubscriptionVersion,
+		Timestamp: msg.Metadata.MessageTimestamp,
+		Event:     msg.Payload.Event,
+	}
+	for _, h := range handlers {
+		h(n)
+	}
+}
+
+// subscribe は Helix でこのセッション宛てのサブスクリプションを作成します。
+// 権限不足などで一部が失敗してもログだけ残し、1つも作成できなかった場合だけエラーにします
+func (c *Client) subscribe(sessionID string) error {
+	token, err := c.Token()
+	if err != nil {
+		return fmt.Errorf("eventsub: token: %w", err)
+	}
+	token = strings.TrimPrefix(token, "oauth:")
+
+	created := 0
+	for _, sub := range c.Subscriptions {
+		resp, err := c.http.R().
+			SetHeader("Client-Id", c.ClientID).
+			SetHeader("Authorization", "Bearer "+token).
+			SetBody(map[string]interface{}{
+				"type":      sub.Type,
+				"version":   sub.Version,
+				"condition": sub.Condition,
+				"transport": map[string]string{"method": "websocket", "session_id": sessionID},
+			}).
+			Post(strings.TrimRight(c.HelixURL, "/") + "/eventsub/subscriptions")
+		if err != nil {
+			log.Printf("eventsub: subscribe %s: %v", sub.Type, err)
+			continue
+		}
+		switch {
+		case resp.StatusCode() == http.StatusConflict:
+			// 既に作成済み
+		case resp.IsError():
+			log.Printf("eventsub: subscribe %s: %s %s", sub.Type, resp.Status(), resp.String())
+			continue
+		}
+		created++
+	}
+	if created == 0 && len(c.Subscriptions) > 0 {
+		return errors.New("eventsub: no subscription created")
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package eventsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// fakeServer は EventSub の WebSocket と Helix の /eventsub/subscriptions を兼ねるスタブです
+type fakeServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	sessions []string // サブスクリプション作成時に指定された session_id
+	auth     []string
+}
+
+func welcome(id string) string {
+	return fmt.Sprintf(`{"metadata":{"message_id":"w-%s","message_type":"session_welcome"},
+		"payload":{"session":{"id":"%s","status":"connected","keepalive_timeout_seconds":10}}}`, id, id)
+}
+
+func notification(id, subType, event string) string {
+	return fmt.Sprintf(`{"metadata":{"message_id":"%s","message_type":"notification","subscription_type":"%s","subscription_version":"1"},
+		"payload":{"subscription":{"type":"%s"},"event":%s}}`, id, subType, subType, event)
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	f := &fakeServer{}
+	mux := http.NewServeMux()
+	// 最初の接続: welcome → レイド（同じ message_id を2回）→ /ws2 への乗り換え指示
+	mux.Handle("/ws", websocket.Handler(func(ws *websocket.Conn) {
+		raid := notification("m1", TypeChannelRaid, `{"from_broadcaster_user_name":"Raider","viewers":42}`)
+		reconnect := fmt.Sprintf(`{"metadata":{"message_id":"r1","message_type":"session_reconnect"},
+			"payload":{"session":{"id":"s1","status":"reconnecting","reconnect_url":"%s"}}}`,
+			"ws"+strings.TrimPrefix(f.URL, "http")+"/ws2")
+		for _, m := range []string{welcome("s1"), raid, raid, reconnect} {
+			websocket.Message.Send(ws, m)
+		}
+		io.Copy(io.Discard, ws) // クライアントが閉じるまで待つ
+	}))
+	// 乗り換え先: welcome → 前の接続で届いたレイドの再送 → ビッツ応援
+	mux.Handle("/ws2", websocket.Handler(func(ws *websocket.Conn) {
+		for _, m := range []string{
+			welcome("s2"),
+			notification("m1", TypeChannelRaid, `{"from_broadcaster_user_name":"Raider","viewers":42}`),
+			notification("m2", TypeChannelCheer, `{"user_name":"Fan","bits":100}`),
+		} {
+			websocket.Message.Send(ws, m)
+		}
+		io.Copy(io.Discard, ws)
+	}))
+	mux.HandleFunc("/eventsub/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Type      string            `json:"type"`
+			Transport map[string]string `json:"transport"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.mu.Lock()
+		f.sessions = append(f.sessions, body.Transport["session_id"])
+		f.auth = append(f.auth, r.Header.Get("Authorization"))
+		f.mu.Unlock()
+		if body.Type == TypeChannelCheer {
+			w.WriteHeader(http.StatusConflict) // 既に作成済み
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func newTestClient(f *fakeServer, subs []Subscription) *Client {
+	c := NewClient("cid", func() (string, error) { return "oauth:tok", nil }, subs)
+	c.WebSocketURL = "ws" + strings.TrimPrefix(f.URL, "http") + "/ws"
+	c.HelixURL = f.URL
+	return c
+}
+
+func TestSessionWelcomeReconnectDedup(t *testing.T) {
+	f := newFakeServer(t)
+	c := newTestClient(f, []Subscription{
+		{Type: TypeChannelRaid, Version: "1"},
+		{Type: TypeChannelCheer, Version: "1"},
+	})
+
+	var mu sync.Mutex
+	var raids []RaidEvent
+	c.OnRaid(func(ev RaidEvent) {
+		mu.Lock()
+		raids = append(raids, ev)
+		mu.Unlock()
+	})
+	cheered := make(chan CheerEvent, 1)
+	c.OnCheer(func(ev CheerEvent) { cheered <- ev })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+
+	select {
+	case ev := <-cheered:
+		if ev.UserName != "Fan" || ev.Bits != 100 {
+			t.Errorf("cheer = %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("cheer was not delivered after reconnect")
+	}
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Run() = %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(raids) != 1 || raids[0].FromBroadcasterUserName != "Raider" || raids[0].Viewers != 42 {
+		t.Errorf("raids = %+v, want one from Raider", raids)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// サブスクリプションは最初の welcome で1度だけ作り、乗り換え後は作り直さない
+	if fmt.Sprint(f.sessions) != "[s1 s1]" {
+		t.Errorf("subscribed sessions = %v, want [s1 s1]", f.sessions)
+	}
+	for _, a := range f.auth {
+		if a != "Bearer tok" {
+			t.Errorf("Authorization = %q, want %q", a, "Bearer tok")
+		}
+	}
+}
+
+func TestSessionFailsWhenNoSubscriptionCreated(t *testing.T) {
+	f := newFakeServer(t)
+	c := newTestClient(f, []Subscription{{Type: "channel.unknown", Version: "1"}})
+	// スタブは channel.unknown に 202 を返すので、エラーになるよう Helix の接続先を壊す
+	c.HelixURL = f.URL + "/missing"
+	err := c.session(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "no subscription created") {
+		t.Errorf("session() = %v, want no subscription error", err)
+	}
+}
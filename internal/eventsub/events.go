@@ -0,0 +1,103 @@
+package eventsub
+
+import "time"
+
+// サブスクリプションの種類
+const (
+	TypeStreamOnline     = "stream.online"
+	TypeStreamOffline    = "stream.offline"
+	TypeChannelFollow    = "channel.follow"
+	TypeChannelRaid      = "channel.raid"
+	TypeChannelSubscribe = "channel.subscribe"
+	TypeChannelCheer     = "channel.cheer"
+)
+
+// Subscription は Helix で作成する EventSub のサブスクリプション1件です
+type Subscription struct {
+	Type      string            `json:"type"`
+	Version   string            `json:"version"`
+	Condition map[string]string `json:"condition"`
+}
+
+// ChannelSubscriptions は配信者1人分の標準的なサブスクリプション
+// （配信開始・終了、フォロー、レイド、サブスク、ビッツ）を返します。
+//   - broadcasterID: 配信者のユーザーID
+//   - moderatorID: channel.follow に必要なモデレーターのユーザーID（トークンの持ち主。空なら配信者）
+func ChannelSubscriptions(broadcasterID, moderatorID string) []Subscription {
+	if moderatorID == "" {
+		moderatorID = broadcasterID
+	}
+	b := map[string]string{"broadcaster_user_id": broadcasterID}
+	return []Subscription{
+		{Type: TypeStreamOnline, Version: "1", Condition: b},
+		{Type: TypeStreamOffline, Version: "1", Condition: b},
+		{Type: TypeChannelFollow, Version: "2", Condition: map[string]string{
+			"broadcaster_user_id": broadcasterID,
+			"moderator_user_id":   moderatorID,
+		}},
+		{Type: TypeChannelRaid, Version: "1", Condition: map[string]string{"to_broadcaster_user_id": broadcasterID}},
+		{Type: TypeChannelSubscribe, Version: "1", Condition: b},
+		{Type: TypeChannelCheer, Version: "1", Condition: b},
+	}
+}
+
+// Broadcaster はイベントの対象チャンネルです
+type Broadcaster struct {
+	BroadcasterUserID    string `json:"broadcaster_user_id"`
+	BroadcasterUserLogin string `json:"broadcaster_user_login"`
+	BroadcasterUserName  string `json:"broadcaster_user_name"`
+}
+
+// StreamOnlineEvent は stream.online のイベントです
+type StreamOnlineEvent struct {
+	Broadcaster
+	ID        string    `json:"id"`
+	Type      string    `json:"type"` // "live" / "playlist" など
+	StartedAt time.Time `json:"started_at"`
+}
+
+// StreamOfflineEvent は stream.offline のイベントです
+type StreamOfflineEvent struct {
+	Broadcaster
+}
+
+// FollowEvent は channel.follow のイベントです
+type FollowEvent struct {
+	Broadcaster
+	UserID     string    `json:"user_id"`
+	UserLogin  string    `json:"user_login"`
+	UserName   string    `json:"user_name"`
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// RaidEvent は channel.raid のイベントです
+type RaidEvent struct {
+	FromBroadcasterUserID    string `json:"from_broadcaster_user_id"`
+	FromBroadcasterUserLogin string `json:"from_broadcaster_user_login"`
+	FromBroadcasterUserName  string `json:"from_broadcaster_user_name"`
+	ToBroadcasterUserID      string `json:"to_broadcaster_user_id"`
+	ToBroadcasterUserLogin   string `json:"to_broadcaster_user_login"`
+	ToBroadcasterUserName    string `json:"to_broadcaster_user_name"`
+	Viewers                  int    `json:"viewers"`
+}
+
+// SubscribeEvent は channel.subscribe のイベントです
+type SubscribeEvent struct {
+	Broadcaster
+	UserID    string `json:"user_id"`
+	UserLogin string `json:"user_login"`
+	UserName  string `json:"user_name"`
+	Tier      string `json:"tier"` // "1000" / "2000" / "3000"
+	IsGift    bool   `json:"is_gift"`
+}
+
+// CheerEvent は channel.cheer のイベントです（匿名の場合 User* は空）
+type CheerEvent struct {
+	Broadcaster
+	IsAnonymous bool   `json:"is_anonymous"`
+	UserID      string `json:"user_id"`
+	UserLogin   string `json:"user_login"`
+	UserName    string `json:"user_name"`
+	Message     string `json:"message"`
+	Bits        int    `json:"bits"`
+}
@@ -0,0 +1,177 @@
+package helix
+
+import (
+	"net/url"
+	"time"
+)
+
+// Stream は /streams の配信情報です
+type Stream struct {
+	ID          string    `json:"id"`
+	UserID      string    `json:"user_id"`
+	UserLogin   string    `json:"user_login"`
+	UserName    string    `json:"user_name"`
+	GameID      string    `json:"game_id"`
+	GameName    string    `json:"game_name"`
+	Type        string    `json:"type"`
+	Title       string    `json:"title"`
+	ViewerCount int       `json:"viewer_count"`
+	StartedAt   time.Time `json:"started_at"`
+	Language    string    `json:"language"`
+	Tags        []string  `json:"tags"`
+	IsMature    bool      `json:"is_mature"`
+}
+
+// StreamsQuery は /streams の検索条件です
+type StreamsQuery struct {
+	UserIDs    []string
+	UserLogins []string
+	GameIDs    []string
+	Language   string
+	Limit      int // 最大件数（0 なら全件）
+}
+
+// Streams は配信中のストリームを視聴者数の多い順に取得します
+func (c *Client) Streams(q StreamsQuery) ([]Stream, error) {
+	v := url.Values{}
+	for _, id := range q.UserIDs {
+		v.Add("user_id", id)
+	}
+	for _, login := range q.UserLogins {
+		v.Add("user_login", login)
+	}
+	for _, id := range q.GameIDs {
+		v.Add("game_id", id)
+	}
+	if q.Language != "" {
+		v.Set("language", q.Language)
+	}
+	return list[Stream](c, "streams", v, q.Limit)
+}
+
+// Stream は指定したチャンネルの配信情報を返します。配信していなければ nil を返します
+func (c *Client) Stream(login string) (*Stream, error) {
+	streams, err := c.Streams(StreamsQuery{UserLogins: []string{login}, Limit: 1})
+	if err != nil || len(streams) == 0 {
+		return nil, err
+	}
+	return &streams[0], nil
+}
+
+// Game はカテゴリ（ゲーム）です
+type Game struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	BoxArtURL string `json:"box_art_url"`
+	IGDBID    string `json:"igdb_id"`
+}
+
+// TopGames は視聴者数の多いカテゴリを最大 limit 件（0 なら全件）取得します
+func (c *Client) TopGames(limit int) ([]Game, error) {
+	return list[Game](c, "games/top", nil, limit)
+}
+
+// SearchCategories はカテゴリを名前で検索します
+func (c *Client) SearchCategories(query string, limit int) ([]Game, error) {
+	return list[Game](c, "search/categories", url.Values{"query": {query}}, limit)
+}
+
+// User はユーザー情報です
+type User struct {
+	ID              string    `json:"id"`
+	Login           string    `json:"login"`
+	DisplayName     string    `json:"display_name"`
+	Type            string    `json:"type"`
+	BroadcasterType string    `json:"broadcaster_type"`
+	Description     string    `json:"description"`
+	ProfileImageURL string    `json:"profile_image_url"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Users は ID またはログイン名でユーザー情報を取得します（合わせて100件まで）
+func (c *Client) Users(ids, logins []string) ([]User, error) {
+	v := url.Values{}
+	for _, id := range ids {
+		v.Add("id", id)
+	}
+	for _, login := range logins {
+		v.Add("login", login)
+	}
+	var p page[User]
+	if err := c.get("users", v, &p); err != nil {
+		return nil, err
+	}
+	return p.Data, nil
+}
+
+// Channel はチャンネル情報です（配信していなくても取得できる）
+type Channel struct {
+	BroadcasterID       string   `json:"broadcaster_id"`
+	BroadcasterLogin    string   `json:"broadcaster_login"`
+	BroadcasterName     string   `json:"broadcaster_name"`
+	BroadcasterLanguage string   `json:"broadcaster_language"`
+	GameID              string   `json:"game_id"`
+	GameName            string   `json:"game_name"`
+	Title               string   `json:"title"`
+	Delay               int      `json:"delay"`
+	Tags                []string `json:"tags"`
+}
+
+// Channels は配信者IDでチャンネル情報を取得します（100件まで）
+func (c *Client) Channels(broadcasterIDs ...string) ([]Channel, error) {
+	v := url.Values{}
+	for _, id := range broadcasterIDs {
+		v.Add("broadcaster_id", id)
+	}
+	var p page[Channel]
+	if err := c.get("channels", v, &p); err != nil {
+		return nil, err
+	}
+	return p.Data, nil
+}
+
+// Clip はクリップです
+type Clip struct {
+	ID              string    `json:"id"`
+	URL             string    `json:"url"`
+	EmbedURL        string    `json:"embed_url"`
+	BroadcasterID   string    `json:"broadcaster_id"`
+	BroadcasterName string    `json:"broadcaster_name"`
+	CreatorID       string    `json:"creator_id"`
+	CreatorName     string    `json:"creator_name"`
+	VideoID         string    `json:"video_id"`
+	GameID          string    `json:"game_id"`
+	Language        string    `json:"language"`
+	Title           string    `json:"title"`
+	ViewCount       int       `json:"view_count"`
+	CreatedAt       time.Time `json:"created_at"`
+	ThumbnailURL    string    `json:"thumbnail_url"`
+	Duration        float64   `json:"duration"` // 秒
+}
+
+// ClipsQuery は /clips の検索条件です（BroadcasterID / GameID のどちらかが必須）
+type ClipsQuery struct {
+	BroadcasterID string
+	GameID        string
+	StartedAt     time.Time // 指定した期間に作られたクリップだけ（ゼロ値なら指定なし）
+	EndedAt       time.Time
+	Limit         int // 最大件数（0 なら全件）
+}
+
+// Clips はクリップを再生数の多い順に取得します
+func (c *Client) Clips(q ClipsQuery) ([]Clip, error) {
+	v := url.Values{}
+	if q.BroadcasterID != "" {
+		v.Set("broadcaster_id", q.BroadcasterID)
+	}
+	if q.GameID != "" {
+		v.Set("game_id", q.GameID)
+	}
+	if !q.StartedAt.IsZero() {
+		v.Set("started_at", q.StartedAt.UTC().Format(time.RFC3339))
+	}
+	if !q.EndedAt.IsZero() {
+		v.Set("ended_at", q.EndedAt.UTC().Format(time.RFC3339))
+	}
+	return list[Clip](c, "clips", v, q.Limit)
+}
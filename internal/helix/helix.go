@@ -0,0 +1,175 @@
+package helix
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL は Helix API の接続先です。テストでは Client.BaseURL をローカルのスタブに差し替えます
+const DefaultBaseURL = "https://api.twitch.tv/helix"
+
+// maxPerPage は1リクエストで取得できる最大件数（first の上限）です
+const maxPerPage = 100
+
+// Doer は認証ヘッダーを付けてリクエストを送るものです。
+// twitchauth.AppTokenSource（Client-ID / Bearer の付与と 401 時の取り直しを行う）を渡します
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// APIError は Helix がエラーを返したことを示します
+type APIError struct {
+	StatusCode int
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("helix: %d %s", e.StatusCode, e.Message)
+}
+
+// Client は Helix API のクライアントです。
+// Ratelimit-Remaining / Ratelimit-Reset ヘッダーを見て、残りがなければリセットまで待ってから送ります
+type Client struct {
+	BaseURL string
+	auth    Doer
+
+	mu        sync.Mutex
+	remaining int       // 残りのポイント（-1 なら不明）
+	reset     time.Time // ポイントが回復する時刻
+}
+
+// New は Helix クライアントを作成します
+func New(auth Doer) *Client {
+	return &Client{BaseURL: DefaultBaseURL, auth: auth, remaining: -1}
+}
+
+// page はページ送りのある応答の共通部分です
+type page[T any] struct {
+	Data       []T `json:"data"`
+	Pagination struct {
+		Cursor string `json:"cursor"`
+	} `json:"pagination"`
+}
+
+// get は GET リクエストを送って JSON を out に読み込みます。
+// レート制限(429)に当たった場合はリセットまで待って送り直します
+func (c *Client) get(path string, q url.Values, out interface{}) error {
+	u := strings.TrimRight(c.BaseURL, "/") + "/" + strings.TrimLeft(path, "/")
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	for attempt := 0; ; attempt++ {
+		c.waitRateLimit()
+		req, err := http.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := c.auth.Do(req)
+		if err != nil {
+			return err
+		}
+		c.updateRateLimit(resp.Header)
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < 3 {
+			resp.Body.Close()
+			c.mu.Lock()
+			c.remaining = 0
+			if time.Until(c.reset) <= 0 {
+				// ヘッダーがない場合は少し待つ
+				c.reset = time.Now().Add(time.Second)
+			}
+			c.mu.Unlock()
+			log.Printf("helix: rate limited, waiting until %s", c.resetTime().Format("15:04:05"))
+			continue
+		}
+		err = decode(resp, out)
+		resp.Body.Close()
+		return err
+	}
+}
+
+// decode は応答を読み込みます。2xx 以外は APIError にします
+func decode(resp *http.Response, out interface{}) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		json.Unmarshal(body, apiErr)
+		return apiErr
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("helix: %v", err)
+	}
+	return nil
+}
+
+// updateRateLimit は応答ヘッダーから残りポイントとリセット時刻を記録します
+func (c *Client) updateRateLimit(h http.Header) {
+	remaining, err1 := strconv.Atoi(h.Get("Ratelimit-Remaining"))
+	reset, err2 := strconv.ParseInt(h.Get("Ratelimit-Reset"), 10, 64)
+	if err1 != nil || err2 != nil {
+		return
+	}
+	c.mu.Lock()
+	c.remaining, c.reset = remaining, time.Unix(reset, 0)
+	c.mu.Unlock()
+}
+
+// waitRateLimit は残りポイントがなければリセット時刻まで待ちます
+func (c *Client) waitRateLimit() {
+	c.mu.Lock()
+	wait := time.Duration(0)
+	if c.remaining == 0 {
+		wait = time.Until(c.reset)
+		// 待ち終わったら次の応答で正しい値が入るまで不明扱い
+		c.remaining = -1
+	}
+	c.mu.Unlock()
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// resetTime はポイントが回復する時刻を返します
+func (c *Client) resetTime() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reset
+}
+
+// list は pagination.cursor をたどって最大 limit 件（0 なら全件）を取得します
+func list[T any](c *Client, path string, q url.Values, limit int) ([]T, error) {
+	if q == nil {
+		q = url.Values{}
+	}
+	var all []T
+	seen := map[string]bool{}
+	for {
+		first := maxPerPage
+		if limit > 0 && limit-len(all) < first {
+			first = limit - len(all)
+		}
+		q.Set("first", strconv.Itoa(first))
+		var p page[T]
+		if err := c.get(path, q, &p); err != nil {
+			return all, err
+		}
+		all = append(all, p.Data...)
+		cursor := p.Pagination.Cursor
+		// 同じカーソルが返ってきた場合も無限ループにならないよう終了する
+		if (limit > 0 && len(all) >= limit) || cursor == "" || len(p.Data) == 0 || seen[cursor] {
+			return all, nil
+		}
+		seen[cursor] = true
+		q.Set("after", cursor)
+	}
+}
@@ -0,0 +1,147 @@
+package helix
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient は handler をスタブにした Client を返します
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c := New(http.DefaultClient)
+	c.BaseURL = srv.URL
+	return c
+}
+
+// pagedGames は games/top を first 件ずつ、cursor に次の位置を入れて返すスタブです
+func pagedGames(total int, firsts *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		*firsts = append(*firsts, q.Get("first"))
+		start, _ := strconv.Atoi(q.Get("after"))
+		n, _ := strconv.Atoi(q.Get("first"))
+		end := min(start+n, total)
+		var data []string
+		for i := start; i < end; i++ {
+			data = append(data, fmt.Sprintf(`{"id":"%d"}`, i))
+		}
+		cursor := ""
+		if end < total {
+			cursor = strconv.Itoa(end)
+		}
+		fmt.Fprintf(w, `{"data":[%s],"pagination":{"cursor":"%s"}}`, strings.Join(data, ","), cursor)
+	}
+}
+
+func TestListFollowsCursor(t *testing.T) {
+	var firsts []string
+	c := newTestClient(t, pagedGames(250, &firsts))
+	games, err := c.TopGames(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 250 || games[0].ID != "0" || games[249].ID != "249" {
+		t.Errorf("got %d games", len(games))
+	}
+	if fmt.Sprint(firsts) != "[100 100 100]" {
+		t.Errorf("first = %v", firsts)
+	}
+}
+
+func TestListLimit(t *testing.T) {
+	var firsts []string
+	c := newTestClient(t, pagedGames(250, &firsts))
+	games, err := c.TopGames(130)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 130 {
+		t.Errorf("got %d games, want 130", len(games))
+	}
+	// 2ページ目は残りの件数だけ要求する
+	if fmt.Sprint(firsts) != "[100 30]" {
+		t.Errorf("first = %v", firsts)
+	}
+}
+
+func TestListStopsOnRepeatedCursor(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		fmt.Fprint(w, `{"data":[{"id":"1"}],"pagination":{"cursor":"same"}}`)
+	})
+	games, err := c.TopGames(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 2 || calls != 2 {
+		t.Errorf("got %d games in %d calls, want 2 in 2", len(games), calls)
+	}
+}
+
+func TestGetWaitsOnRateLimit(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// リセット時刻が過去なら1秒待って送り直す
+			w.Header().Set("Ratelimit-Remaining", "0")
+			w.Header().Set("Ratelimit-Reset", strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Ratelimit-Remaining", "799")
+		w.Header().Set("Ratelimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+		fmt.Fprint(w, `{"data":[{"id":"9","login":"mary"}]}`)
+	})
+	start := time.Now()
+	users, err := c.Users(nil, []string{"mary"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("retried after %s, want about 1s", elapsed)
+	}
+	if calls != 2 || len(users) != 1 || users[0].Login != "mary" {
+		t.Errorf("calls = %d, users = %+v", calls, users)
+	}
+}
+
+// 429 が続く場合は3回まで待って送り直し、あきらめたら APIError を返す
+func TestGetGivesUpOnRateLimit(t *testing.T) {
+	var calls int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Ratelimit-Remaining", "0")
+		w.Header().Set("Ratelimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	_, err := c.Channels("1")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("err = %v, want APIError 429", err)
+	}
+	if calls != 4 {
+		t.Errorf("calls = %d, want 4", calls)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"Bad Request","status":400,"message":"Invalid login names"}`)
+	})
+	_, err := c.Stream("bad name")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Message != "Invalid login names" {
+		t.Errorf("err = %v", err)
+	}
+}
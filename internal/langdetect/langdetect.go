@@ -0,0 +1,127 @@
+package langdetect
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Result は言語判定の結果です
+type Result struct {
+	Lang       string  // ISO 639-1 の言語コード（"ja" / "en" / "ko" など、判定不能なら空）
+	Confidence float64 // 0〜1 の確からしさ
+}
+
+// 中国語でしか使わない（日本語ではまず出てこない）よく使う字（簡体字・繁体字）
+const chineseOnly = "们这说么吗没为时对还给让从个过见长东车门问间话边题风飞马鸟语读写买卖钱认识应该觉样吧呢啊你您她谢哪怎啥咱哦嗯呀啦嘛喔欸" +
+	"們這說麼嗎沒為會來對讓從邊讀寫賣錢應覺樣妳歡點裡聽"
+
+// 中国語の文によく出る機能語の字。日本語の熟語にも使われるので、1字だけでは判定に使わない
+const chineseFunction = "的是不我他在有了也就都很和要谢謝好"
+
+// ラテン文字の言語ごとの頻出語（チャットで多いもの）
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "you", "it", "to", "of", "what", "this", "that", "i", "are", "my", "your", "hello", "hi", "lol", "gg", "nice", "thanks", "thank", "how", "do", "so", "be", "in"},
+	"es": {"el", "la", "los", "las", "que", "es", "de", "y", "hola", "gracias", "por", "muy", "bien", "como", "qué", "está", "pero", "una", "un", "yo", "tu", "buenas", "jaja"},
+	"pt": {"o", "os", "as", "que", "é", "de", "e", "olá", "ola", "obrigado", "obrigada", "muito", "bem", "como", "você", "voce", "não", "nao", "uma", "um", "eu", "tudo", "kkk", "bom"},
+}
+
+// Detect は文字種と頻出語からメッセージの言語を推定します。
+//   - ひらがな・カタカナ → ja（英単語が混ざっていても日本語とみなす）
+//   - ハングル → ko
+//   - 漢字のみ → 中国語特有の字があるか、機能語の字（的 是 我 など）が2字以上かつ漢字の1/3以上なら zh、
+//     なければ ja（"草" "了解" など）
+//   - ラテン文字 → 頻出語と記号（ñ ¿ ã ç など）で en / es / pt
+//   - キリル文字・タイ文字など上記以外の文字が多い → 言語は空（判定不能。"*" のルートで翻訳する）
+func Detect(text string) Result {
+	var kana, hangul, han, latin, other, total int
+	chinese, function := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Han, r):
+			han++
+			if strings.ContainsRune(chineseOnly, r) {
+				chinese++
+			} else if strings.ContainsRune(chineseFunction, r) {
+				function++
+			}
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.IsLetter(r):
+			other++
+		default:
+			continue
+		}
+		total++
+	}
+	if total == 0 {
+		return Result{}
+	}
+
+	switch {
+	case kana > 0:
+		// かながあれば日本語でほぼ確実（"Apexやろう" のような英単語混じりも多い）
+		return Result{Lang: "ja", Confidence: 0.7 + 0.3*ratio(kana+han, total)}
+	case hangul > 0 && hangul >= han:
+		return Result{Lang: "ko", Confidence: ratio(hangul, total)}
+	case han > 0 && han >= latin:
+		if chinese > 0 || (function >= 2 && function*3 >= han) {
+			return Result{Lang: "zh", Confidence: 0.5 + 0.5*ratio(chinese+function, han)}
+		}
+		// 漢字だけの短文は日本語のことが多い（配信が日本語のため）
+		return Result{Lang: "ja", Confidence: 0.6 * ratio(han, total)}
+	case other > latin:
+		return Result{}
+	}
+	return detectLatin(text)
+}
+
+// detectLatin はラテン文字の文を en / es / pt に振り分けます
+func detectLatin(text string) Result {
+	lower := strings.ToLower(text)
+	scores := map[string]float64{}
+	if strings.ContainsAny(lower, "ñ¿¡") {
+		scores["es"] += 2
+	}
+	if strings.ContainsAny(lower, "ãõç") {
+		scores["pt"] += 2
+	}
+	words := strings.FieldsFunc(lower, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	for _, w := range words {
+		for lang, list := range stopwords {
+			for _, sw := range list {
+				if w == sw {
+					scores[lang]++
+				}
+			}
+		}
+	}
+
+	best, bestScore, sum := "en", 0.0, 0.0
+	for _, lang := range []string{"en", "es", "pt"} {
+		sum += scores[lang]
+		if scores[lang] > bestScore {
+			best, bestScore = lang, scores[lang]
+		}
+	}
+	if sum == 0 {
+		// 手がかりがなければ英語とみなす（確からしさは低め。"www" のような1語だけなら翻訳しない程度）
+		if len(words) >= 3 {
+			return Result{Lang: "en", Confidence: 0.5}
+		}
+		return Result{Lang: "en", Confidence: 0.3}
+	}
+	return Result{Lang: best, Confidence: bestScore / sum}
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
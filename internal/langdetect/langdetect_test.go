@@ -0,0 +1,113 @@
+package langdetect
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    string
+		minConf float64 // この確からしさ以上であること
+		maxConf float64 // 0 なら上限なし
+	}{
+		// 日本語
+		{"こんにちは", "ja", 1, 0},
+		{"草", "ja", 0.5, 0},
+		{"了解", "ja", 0.5, 0},
+		{"会議", "ja", 0.5, 0},
+		{"Apexやろう", "ja", 0.7, 0},
+		{"今日はgood game", "ja", 0.7, 0},
+		// 中国語（簡体字・繁体字）
+		{"你好", "zh", 0.5, 0},
+		{"谢谢大家", "zh", 0.5, 0},
+		{"謝謝大家", "zh", 0.5, 0},
+		{"你好嗎", "zh", 0.5, 0},
+		{"這個很好", "zh", 0.5, 0},
+		{"我是学生", "zh", 0.5, 0},
+		// 韓国語
+		{"안녕하세요", "ko", 1, 0},
+		// ラテン文字
+		{"hello, how are you", "en", 0.9, 0},
+		{"hola, gracias por el stream", "es", 0.5, 0},
+		{"olá, tudo bem", "pt", 0.5, 0},
+		{"¿qué pasa?", "es", 0.5, 0},
+		// 頻出語のない短い英語は en だが確からしさは低い
+		{"good game", "en", 0, 0.5},
+		{"www", "en", 0, 0.5},
+		// 判定できない文字・文字のない発言
+		{"Привет всем", "", 0, 0},
+		{"8888", "", 0, 0},
+		{"", "", 0, 0},
+	}
+	for _, tt := range tests {
+		got := Detect(tt.text)
+		if got.Lang != tt.want {
+			t.Errorf("Detect(%q).Lang = %q, want %q", tt.text, got.Lang, tt.want)
+			continue
+		}
+		if got.Confidence < tt.minConf || (tt.maxConf > 0 && got.Confidence >= tt.maxConf) {
+			t.Errorf("Detect(%q).Confidence = %.2f, want [%.2f, %.2f)", tt.text, got.Confidence, tt.minConf, tt.maxConf)
+		}
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	routes, err := ParseRoutes("ja=EN, ko=JA|EN, en=, *=JA")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"ja", "[EN]"},
+		{"ko", "[JA EN]"},
+		{"en", "[]"}, // 翻訳先が空なら翻訳しない
+		{"es", "[JA]"},
+		{"", "[JA]"}, // 判定できない言語も "*" に従う
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprint(routes.Targets(tt.lang)); got != tt.want {
+			t.Errorf("Targets(%q) = %s, want %s", tt.lang, got, tt.want)
+		}
+	}
+
+	// 翻訳元と同じ言語は除く
+	if got := fmt.Sprint(Routes{"*": {"EN-US", "JA"}}.Targets("en")); got != "[JA]" {
+		t.Errorf("Targets(en) = %s, want [JA]", got)
+	}
+}
+
+func TestParseRoutesDefault(t *testing.T) {
+	routes, err := ParseRoutes("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(routes.Targets("ja"), routes.Targets("en")); got != "[EN] [JA]" {
+		t.Errorf("default routes = %s", got)
+	}
+	if _, err := ParseRoutes("ja"); err == nil {
+		t.Error("ParseRoutes(\"ja\") should fail")
+	}
+}
+
+func TestSameLang(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"en", "EN-US", true},
+		{"EN-GB", "en-us", true},
+		{"ja", "JA", true},
+		{"pt", "PT-BR", true},
+		{"en", "JA", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got := SameLang(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameLang(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package langdetect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultRoutes は日本語→英語、それ以外→日本語（従来の動き）です
+const DefaultRoutes = "ja=EN,*=JA"
+
+// Routes は翻訳元の言語から翻訳先の言語（DeepL 形式の大文字コード）への対応表です。
+// "*" はどれにも当てはまらない場合の既定値です
+type Routes map[string][]string
+
+// ParseRoutes は "ja=EN,en=JA,ko=JA|EN,*=JA" 形式の設定を読み込みます。
+// 翻訳先を "|" で区切ると複数の言語へ翻訳します。翻訳先を空にすると翻訳しません（例: "ja="）
+func ParseRoutes(spec string) (Routes, error) {
+	if strings.TrimSpace(spec) == "" {
+		spec = DefaultRoutes
+	}
+	routes := Routes{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		src, dst, ok := strings.Cut(item, "=")
+		if !ok {
+			return nil, fmt.Errorf("langdetect: invalid route %q", item)
+		}
+		var targets []string
+		for _, t := range strings.Split(dst, "|") {
+			if t = strings.ToUpper(strings.TrimSpace(t)); t != "" {
+				targets = append(targets, t)
+			}
+		}
+		routes[strings.ToLower(strings.TrimSpace(src))] = targets
+	}
+	return routes, nil
+}
+
+// Targets は翻訳元の言語に対する翻訳先を返します。翻訳元と同じ言語は除きます
+func (r Routes) Targets(lang string) []string {
+	targets, ok := r[lang]
+	if !ok {
+		targets = r["*"]
+	}
+	var out []string
+	for _, t := range targets {
+		if !SameLang(lang, t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// SameLang は "en" と "EN-US" のように表記が違っても同じ言語かどうかを判定します
+func SameLang(a, b string) bool {
+	base := func(s string) string {
+		s = strings.ToLower(s)
+		if i := strings.Index(s, "-"); i > 0 {
+			s = s[:i]
+		}
+		return s
+	}
+	return a != "" && base(a) == base(b)
+}
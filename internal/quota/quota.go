@@ -0,0 +1,159 @@
+package quota
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Level は残量に応じた翻訳の制限段階です
+type Level int
+
+const (
+	LevelNormal      Level = iota // 制限なし
+	LevelSkipLong                 // 長文をスキップ
+	LevelForeignOnly              // 日本語以外の発言だけ翻訳
+	LevelStopped                  // 翻訳停止
+)
+
+// 1日の予算に対する使用率のしきい値（この割合を超えると次の段階へ）
+var thresholds = map[Level]float64{
+	LevelSkipLong:    0.8,
+	LevelForeignOnly: 1.0,
+	LevelStopped:     1.2,
+}
+
+// String はチャット通知用の説明を返します
+func (l Level) String() string {
+	switch l {
+	case LevelSkipLong:
+		return "長文スキップ"
+	case LevelForeignOnly:
+		return "日本語以外のみ翻訳"
+	case LevelStopped:
+		return "翻訳停止"
+	default:
+		return "通常"
+	}
+}
+
+// Manager は DeepL の残量から1日の予算を計算し、使いすぎたら段階的に翻訳を制限します
+type Manager struct {
+	billingDay int // 請求期間がリセットされる日（1〜28）
+	longChars  int // LevelSkipLong でスキップする文字数
+
+	// OnLevelChange は制限段階が変わったときに呼ばれます（モデレーターへのチャット通知用）
+	OnLevelChange func(level Level, status Status)
+
+	mu       sync.Mutex
+	count    int
+	limit    int
+	day      string // dayStart を記録した日付（"2006-01-02"）
+	dayStart int    // その日の最初に取得した使用文字数
+	budget   int    // その日の予算
+	level    Level
+}
+
+// Status は現在の残量と予算です
+type Status struct {
+	Count     int   // 今月の使用文字数
+	Limit     int   // 今月の上限
+	UsedToday int   // 今日の使用文字数
+	Budget    int   // 今日の予算
+	Level     Level // 制限段階
+}
+
+// New は Manager を作成します。billingDay が範囲外なら毎月1日、longChars が0以下なら100文字とします
+func New(billingDay, longChars int) *Manager {
+	if billingDay < 1 || billingDay > 28 {
+		billingDay = 1
+	}
+	if longChars <= 0 {
+		longChars = 100
+	}
+	return &Manager{billingDay: billingDay, longChars: longChars}
+}
+
+// Update は取得した使用量（今月の使用文字数と上限）で予算と制限段階を更新します
+func (m *Manager) Update(count, limit int, now time.Time) {
+	m.mu.Lock()
+	m.count, m.limit = count, limit
+	today := now.Format("2006-01-02")
+	// 日付が変わったら（または請求期間がリセットされて使用量が減ったら）その日の予算を計算し直す
+	if m.day != today || count < m.dayStart {
+		m.day, m.dayStart = today, count
+		m.budget = (limit - count) / daysLeft(now, m.billingDay)
+	}
+	old := m.level
+	m.level = m.levelLocked()
+	status := m.statusLocked()
+	m.mu.Unlock()
+
+	if status.Level != old && m.OnLevelChange != nil {
+		m.OnLevelChange(status.Level, status)
+	}
+}
+
+// levelLocked は今日の使用率から制限段階を決めます（ロック済みで呼ぶ）
+func (m *Manager) levelLocked() Level {
+	if m.limit > 0 && m.count >= m.limit {
+		return LevelStopped
+	}
+	if m.budget <= 0 {
+		return LevelStopped
+	}
+	ratio := float64(m.count-m.dayStart) / float64(m.budget)
+	for _, l := range []Level{LevelStopped, LevelForeignOnly, LevelSkipLong} {
+		if ratio >= thresholds[l] {
+			return l
+		}
+	}
+	return LevelNormal
+}
+
+func (m *Manager) statusLocked() Status {
+	return Status{Count: m.count, Limit: m.limit, UsedToday: m.count - m.dayStart, Budget: m.budget, Level: m.level}
+}
+
+// Status は現在の状態を返します
+func (m *Manager) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.statusLocked()
+}
+
+// Allow は現在の制限段階でこのメッセージを翻訳してよいかを返します
+//   - text: 翻訳するテキスト
+//   - lang: 判定した言語（"ja" など）
+func (m *Manager) Allow(text, lang string) bool {
+	m.mu.Lock()
+	level := m.level
+	m.mu.Unlock()
+
+	switch level {
+	case LevelStopped:
+		return false
+	case LevelForeignOnly:
+		return lang != "ja"
+	case LevelSkipLong:
+		return utf8.RuneCountInString(text) <= m.longChars
+	}
+	return true
+}
+
+// Summary は !status 用に "今日 1234/5000" の形式で返します
+func (s Status) Summary() string {
+	return fmt.Sprintf("今日 %d/%d (%s)", s.UsedToday, s.Budget, s.Level)
+}
+
+// daysLeft は今日を含めて、次の請求期間のリセット日までの日数を返します
+func daysLeft(now time.Time, billingDay int) int {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	reset := time.Date(now.Year(), now.Month(), billingDay, 0, 0, 0, 0, now.Location())
+	if !reset.After(today) {
+		reset = reset.AddDate(0, 1, 0)
+	}
+	return int(math.Max(1, math.Round(reset.Sub(today).Hours()/24)))
+}
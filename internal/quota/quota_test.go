@@ -0,0 +1,76 @@
+package quota
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLevelLocked(t *testing.T) {
+	tests := []struct {
+		name                      string
+		count, limit, start, budg int
+		want                      Level
+	}{
+		{"予算内", 1000, 100000, 0, 6000, LevelNormal},
+		{"80%未満", 4799, 100000, 0, 6000, LevelNormal},
+		{"80%", 4800, 100000, 0, 6000, LevelSkipLong},
+		{"100%", 6000, 100000, 0, 6000, LevelForeignOnly},
+		{"120%", 7200, 100000, 0, 6000, LevelStopped},
+		{"その日の開始分は含めない", 54800, 100000, 50000, 6000, LevelSkipLong},
+		{"今月の上限", 100000, 100000, 99999, 6000, LevelStopped},
+		{"予算なし", 10, 100000, 10, 0, LevelStopped},
+	}
+	for _, tt := range tests {
+		m := &Manager{count: tt.count, limit: tt.limit, dayStart: tt.start, budget: tt.budg}
+		if got := m.levelLocked(); got != tt.want {
+			t.Errorf("%s: levelLocked() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	m := New(1, 10)
+	var changes []Level
+	m.OnLevelChange = func(level Level, st Status) { changes = append(changes, level) }
+
+	// 10/17 → 11/1 まで15日、残り 90000 文字なので1日 6000 文字
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.Local)
+	m.Update(10000, 100000, now)
+	if st := m.Status(); st.Budget != 6000 || st.UsedToday != 0 || st.Level != LevelNormal {
+		t.Fatalf("Status() = %+v", st)
+	}
+
+	m.Update(16000, 100000, now.Add(time.Hour))
+	if got := m.Status().Level; got != LevelForeignOnly {
+		t.Fatalf("Level = %s, want %s", got, LevelForeignOnly)
+	}
+	if m.Allow("こんにちは", "ja") || !m.Allow("hello", "en") {
+		t.Errorf("ForeignOnly: Allow should only pass non-ja messages")
+	}
+
+	// 日付が変わると予算を計算し直して制限が解ける
+	m.Update(16000, 100000, now.AddDate(0, 0, 1))
+	if got := m.Status().Level; got != LevelNormal {
+		t.Errorf("next day Level = %s, want %s", got, LevelNormal)
+	}
+	if len(changes) != 2 || changes[0] != LevelForeignOnly || changes[1] != LevelNormal {
+		t.Errorf("OnLevelChange = %v", changes)
+	}
+}
+
+func TestDaysLeft(t *testing.T) {
+	tests := []struct {
+		now        time.Time
+		billingDay int
+		want       int
+	}{
+		{time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local), 1, 15},
+		{time.Date(2026, 10, 17, 12, 0, 0, 0, time.Local), 20, 3},
+		{time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local), 20, 31},
+	}
+	for _, tt := range tests {
+		if got := daysLeft(tt.now, tt.billingDay); got != tt.want {
+			t.Errorf("daysLeft(%s, %d) = %d, want %d", tt.now.Format("2006-01-02"), tt.billingDay, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package tokenize
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Span はメッセージ中のエモートの位置です（twitch.EmotePosition と同じく文字単位、End を含む）
+type Span struct {
+	Start int
+	End   int
+}
+
+var (
+	reURL         = regexp.MustCompile(`^(https?://|www\.)\S+$`)
+	rePlaceholder = regexp.MustCompile(`<x\s+id="(\d+)"\s*/>|<x\s+id="(\d+)"\s*>\s*</x>`)
+)
+
+// Protect は翻訳で壊されたくない部分（エモート、@メンション、URL、!コマンド、BTTV/7TV のエモート名）を
+// <x id="N"/> というタグに置き換え、残りの本文を XML エスケープして返します。
+// 翻訳後に Restore へ tokens を渡すと元に戻せます。
+//   - emotes: Twitch が IRC タグで教えてくれるエモートの位置
+//   - extra: Twitch のタグに載らない BTTV/7TV などのエモート名
+func Protect(text string, emotes []Span, extra map[string]bool) (masked string, tokens []string) {
+	runes := []rune(text)
+	isEmote := make([]bool, len(runes))
+	for _, e := range emotes {
+		for i := e.Start; i <= e.End && i < len(runes); i++ {
+			if i >= 0 {
+				isEmote[i] = true
+			}
+		}
+	}
+
+	var b strings.Builder
+	placeholder := func(token string) {
+		b.WriteString(fmt.Sprintf(`<x id="%d"/>`, len(tokens)))
+		tokens = append(tokens, token)
+	}
+
+	for i := 0; i < len(runes); {
+		// 空白はそのまま
+		if unicode.IsSpace(runes[i]) {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		// エモート（位置情報どおりに切り出す）
+		if isEmote[i] {
+			j := i
+			for j < len(runes) && isEmote[j] {
+				j++
+			}
+			placeholder(string(runes[i:j]))
+			i = j
+			continue
+		}
+		// 空白までを1語として判定
+		j := i
+		for j < len(runes) && !unicode.IsSpace(runes[j]) && !isEmote[j] {
+			j++
+		}
+		word := string(runes[i:j])
+		if protectedWord(word, extra) {
+			placeholder(word)
+		} else {
+			b.WriteString(html.EscapeString(word))
+		}
+		i = j
+	}
+	return b.String(), tokens
+}
+
+// protectedWord はそのまま残すべき語かどうかを判定します
+func protectedWord(word string, extra map[string]bool) bool {
+	switch {
+	case strings.HasPrefix(word, "@") && len(word) > 1:
+		return true
+	case strings.HasPrefix(word, "!") && len(word) > 1:
+		return true
+	case reURL.MatchString(word):
+		return true
+	}
+	return extra[word]
+}
+
+// Restore は翻訳結果のタグを元の語に戻し、エスケープを解除します。
+// 翻訳でタグが消えてしまった語は末尾に付け足します（エモートが消えないように）
+func Restore(translated string, tokens []string) string {
+	used := make([]bool, len(tokens))
+	var b strings.Builder
+	last := 0
+	for _, m := range rePlaceholder.FindAllStringSubmatchIndex(translated, -1) {
+		b.WriteString(html.UnescapeString(translated[last:m[0]]))
+		idStr := ""
+		if m[2] >= 0 {
+			idStr = translated[m[2]:m[3]]
+		} else {
+			idStr = translated[m[4]:m[5]]
+		}
+		if id, err := strconv.Atoi(idStr); err == nil && id < len(tokens) {
+			b.WriteString(tokens[id])
+			used[id] = true
+		}
+		last = m[1]
+	}
+	b.WriteString(html.UnescapeString(translated[last:]))
+
+	out := b.String()
+	for i, t := range tokens {
+		if !used[i] {
+			out += " " + t
+		}
+	}
+	return out
+}
+
+// Plain はタグを除いた本文だけを返します（言語判定や空メッセージ判定用）
+func Plain(masked string) string {
+	return strings.TrimSpace(html.UnescapeString(rePlaceholder.ReplaceAllString(masked, " ")))
+}
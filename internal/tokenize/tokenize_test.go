@@ -0,0 +1,31 @@
+package tokenize
+
+import "testing"
+
+func TestRestore(t *testing.T) {
+	tokens := []string{"Kappa", "@mary", "https://example.com"}
+	tests := []struct {
+		name       string
+		translated string
+		want       string
+	}{
+		{"自己終了タグ", `<x id="0"/> hello <x id="1"/>`, "Kappa hello @mary https://example.com"},
+		{"開始・終了タグ", `<x id="2"></x> を見て <x id="0"/> <x id="1"/>`, "https://example.com を見て Kappa @mary"},
+		{"エスケープ解除", `Tom &amp; Jerry &lt;3 <x id="0"/> <x id="1"/> <x id="2"/>`, "Tom & Jerry <3 Kappa @mary https://example.com"},
+		{"消えたタグは末尾へ", `こんにちは <x id="1"/>`, "こんにちは @mary Kappa https://example.com"},
+		{"範囲外のIDは無視", `<x id="9"/>hi <x id="0"/><x id="1"/><x id="2"/>`, "hi Kappa@maryhttps://example.com"},
+	}
+	for _, tt := range tests {
+		if got := Restore(tt.translated, tokens); got != tt.want {
+			t.Errorf("%s: Restore(%q) = %q, want %q", tt.name, tt.translated, got, tt.want)
+		}
+	}
+}
+
+func TestProtectRestoreRoundTrip(t *testing.T) {
+	msg := "@mary Kappa see https://example.com & <b>"
+	masked, tokens := Protect(msg, []Span{{Start: 6, End: 10}}, nil)
+	if got := Restore(masked, tokens); got != msg {
+		t.Errorf("Restore(Protect(%q)) = %q", msg, got)
+	}
+}
@@ -0,0 +1,146 @@
+package translate
+
+import (
+	"container/list"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// Cache は翻訳結果をLRUでキャッシュする Translator です。
+// "こんにちは" や "gg" のような定型文で DeepL の文字数を消費しないようにします。
+type Cache struct {
+	next Translator
+	size int
+	path string // 空ならメモリのみ
+
+	mu     sync.Mutex
+	ll     *list.List               // 先頭ほど最近使った
+	items  map[string]*list.Element // キー → 要素
+	hits   int
+	misses int
+	dirty  bool
+}
+
+type cacheEntry struct {
+	Key    string `json:"key"`
+	Result Result `json:"result"`
+}
+
+// NewCache は next の前段にキャッシュを置きます。
+// path を指定するとファイルから読み込み、Save で書き戻せます（再起動してもキャッシュが残る）
+func NewCache(next Translator, size int, path string) (*Cache, error) {
+	if size <= 0 {
+		size = 1000
+	}
+	c := &Cache{next: next, size: size, path: path, ll: list.New(), items: map[string]*list.Element{}}
+	if path == "" {
+		return c, nil
+	}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []cacheEntry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, err
+	}
+	// ファイルは新しい順に保存しているので、古いものから入れ直す
+	for i := len(entries) - 1; i >= 0; i-- {
+		c.add(entries[i].Key, entries[i].Result)
+	}
+	return c, nil
+}
+
+// Name はバックエンド名を返します
+func (c *Cache) Name() string { return "cache>" + c.next.Name() }
+
+// Translate はキャッシュにあればそれを返し、なければ次のバックエンドで翻訳して保存します
+func (c *Cache) Translate(text, targetLang string) (*Result, error) {
+	key := cacheKey(text, targetLang)
+
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		c.hits++
+		res := el.Value.(*cacheEntry).Result
+		c.mu.Unlock()
+		return &res, nil
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	res, err := c.next.Translate(text, targetLang)
+	if err != nil || res == nil {
+		return res, err
+	}
+	c.mu.Lock()
+	c.add(key, *res)
+	c.mu.Unlock()
+	return res, nil
+}
+
+// add はキャッシュに追加し、上限を超えたら最も古いものを捨てます（ロック済みで呼ぶ）
+func (c *Cache) add(key string, res Result) {
+	c.dirty = true
+	if el, ok := c.items[key]; ok {
+		el.Value.(*cacheEntry).Result = res
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&cacheEntry{Key: key, Result: res})
+	for c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).Key)
+	}
+}
+
+// Clear はキャッシュを空にします（用語集の変更後など、訳文が変わる場合に使う）
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	c.ll.Init()
+	c.items = map[string]*list.Element{}
+	c.dirty = true
+	c.mu.Unlock()
+}
+
+// Stats はキャッシュのヒット数とミス数を返します
+func (c *Cache) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+// Save はキャッシュをファイルへ書き込みます（path 未指定や変更なしなら何もしない）
+func (c *Cache) Save() error {
+	c.mu.Lock()
+	if c.path == "" || !c.dirty {
+		c.mu.Unlock()
+		return nil
+	}
+	entries := make([]cacheEntry, 0, c.ll.Len())
+	for el := c.ll.Front(); el != nil; el = el.Next() {
+		entries = append(entries, *el.Value.(*cacheEntry))
+	}
+	c.dirty = false
+	c.mu.Unlock()
+
+	b, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// cacheKey は正規化したテキストと翻訳先言語からキーを作ります
+func cacheKey(text, targetLang string) string {
+	return normalize(text) + "\x00" + targetLang
+}
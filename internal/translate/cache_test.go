@@ -0,0 +1,79 @@
+package translate
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// countingTranslator は呼ばれた回数を数え、入力をそのまま訳文として返します
+type countingTranslator struct {
+	calls map[string]int
+}
+
+func (c *countingTranslator) Name() string { return "counting" }
+
+func (c *countingTranslator) Translate(text, targetLang string) (*Result, error) {
+	c.calls[text]++
+	return &Result{Text: text + ">" + targetLang}, nil
+}
+
+func TestCacheEviction(t *testing.T) {
+	next := &countingTranslator{calls: map[string]int{}}
+	c, err := NewCache(next, 2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a, b を入れたあと a を使うと、c を追加したときに最も古い b が捨てられる
+	for _, text := range []string{"a", "b", "a", "c", "b", "a"} {
+		if _, err := c.Translate(text, "JA"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := map[string]int{"a": 2, "b": 2, "c": 1}
+	for text, n := range want {
+		if next.calls[text] != n {
+			t.Errorf("calls[%q] = %d, want %d", text, next.calls[text], n)
+		}
+	}
+	if hits, misses := c.Stats(); hits != 1 || misses != 5 {
+		t.Errorf("Stats() = %d, %d, want 1, 5", hits, misses)
+	}
+}
+
+func TestCacheKeyNormalized(t *testing.T) {
+	next := &countingTranslator{calls: map[string]int{}}
+	c, _ := NewCache(next, 10, "")
+	c.Translate("Hello  World", "JA")
+	c.Translate("hello world", "JA")
+	c.Translate("hello world", "EN")
+	if len(next.calls) != 2 || next.calls["Hello  World"] != 1 || next.calls["hello world"] != 1 {
+		t.Errorf("calls = %v", next.calls)
+	}
+}
+
+func TestCacheSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	next := &countingTranslator{calls: map[string]int{}}
+	c, _ := NewCache(next, 2, path)
+	for _, text := range []string{"a", "b", "c"} {
+		c.Translate(text, "JA")
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	next = &countingTranslator{calls: map[string]int{}}
+	loaded, err := NewCache(next, 2, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"b", "c"} {
+		res, err := loaded.Translate(text, "JA")
+		if err != nil || res.Text != text+">JA" {
+			t.Errorf("Translate(%q) = %v, %v", text, res, err)
+		}
+	}
+	if len(next.calls) != 0 {
+		t.Errorf("loaded cache missed: %v", next.calls)
+	}
+}
@@ -0,0 +1,47 @@
+package translate
+
+import (
+	"fmt"
+
+	"github.com/k-p5w/go-marybot/internal/deepl"
+	"github.com/k-p5w/go-marybot/internal/langdetect"
+	"github.com/k-p5w/go-marybot/internal/quota"
+	"github.com/k-p5w/go-marybot/internal/tokenize"
+)
+
+// DeepL は DeepL API を使う Translator です
+type DeepL struct {
+	client *deepl.Client
+
+	Glossary *Glossary      // 設定されていれば JA↔EN の翻訳に用語集を使う
+	Quota    *quota.Manager // 設定されていれば1日の予算を超えた翻訳を DeepL に送らない
+}
+
+// NewDeepL は DeepL 用の Translator を作成します
+func NewDeepL(client *deepl.Client) *DeepL {
+	return &DeepL{client: client}
+}
+
+// Name はバックエンド名を返します
+func (d *DeepL) Name() string { return "deepl" }
+
+// Translate は DeepL API で翻訳します。
+// 残量切れ(456)などは deepl.ErrQuotaExceeded などのエラーで、1日の予算による制限は ErrBudgetExceeded で返し、
+// 次のバックエンドへフォールバックさせます（キャッシュの後ろに置くので、キャッシュ済みの訳文は制限を受けない）
+func (d *DeepL) Translate(text, targetLang string) (*Result, error) {
+	plain := tokenize.Plain(text)
+	lang := langdetect.Detect(plain).Lang
+	if d.Quota != nil && !d.Quota.Allow(plain, lang) {
+		return nil, fmt.Errorf("%w (%s)", ErrBudgetExceeded, d.Quota.Status().Level)
+	}
+	// tag_handling=xml で <x id="N"/>（エモートなどの目印）を訳文に残す
+	req := deepl.TranslateRequest{Text: text, TargetLang: targetLang, TagHandling: "xml"}
+	if d.Glossary != nil {
+		req.GlossaryID, req.SourceLang = d.Glossary.IDFor(lang, targetLang)
+	}
+	t, err := d.client.Translate(req)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Text: t.Text, SourceLang: t.DetectedSourceLanguage}, nil
+}
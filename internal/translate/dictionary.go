@@ -0,0 +1,58 @@
+package translate
+
+import (
+	"encoding/json"
+	"html"
+	"os"
+	"strings"
+
+	"github.com/k-p5w/go-marybot/internal/tokenize"
+)
+
+// Dictionary はオフラインの定型文辞書です。DeepL の残量切れ時などのフォールバック用で、
+// メッセージ全体が辞書の見出しと一致した場合だけ訳文を返します。
+//
+// 辞書ファイルの形式（翻訳先の言語コード → 見出し → 訳文）:
+//
+//	{"JA": {"gg": "ナイス試合！"}, "EN": {"草": "lol", "おつ": "good work!"}}
+type Dictionary struct {
+	entries map[string]map[string]string
+}
+
+// LoadDictionary は辞書ファイルを読み込みます
+func LoadDictionary(path string) (*Dictionary, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]map[string]string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	d := &Dictionary{entries: map[string]map[string]string{}}
+	for lang, words := range raw {
+		m := map[string]string{}
+		for k, v := range words {
+			m[normalize(k)] = v
+		}
+		d.entries[strings.ToUpper(lang)] = m
+	}
+	return d, nil
+}
+
+// Name はバックエンド名を返します
+func (d *Dictionary) Name() string { return "dict" }
+
+// Translate は辞書を引きます。見つからなければ ErrNoTranslation を返します
+func (d *Dictionary) Translate(text, targetLang string) (*Result, error) {
+	// エモートなどの目印は外して引く（訳文に無い目印は呼び出し側で末尾に戻される）
+	if v, ok := d.entries[strings.ToUpper(targetLang)][normalize(tokenize.Plain(text))]; ok {
+		return &Result{Text: html.EscapeString(v)}, nil
+	}
+	return nil, ErrNoTranslation
+}
+
+// normalize は前後の空白・大文字小文字・連続空白の違いを吸収します
+func normalize(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,235 @@
+package translate
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/k-p5w/go-marybot/internal/deepl"
+)
+
+// glossaryName は DeepL 上で bot が管理する用語集の名前の接頭辞です
+const glossaryName = "marybot"
+
+// Glossary はローカルのCSV（ja,en）を元に DeepL の用語集を管理します。
+// DeepL の用語集は方向ごと（JA→EN / EN→JA）に1つ必要で、中身の変更ができないため
+// 更新時は新しく作ってから古いものを削除します。
+type Glossary struct {
+	client *deepl.Client
+	path   string
+
+	syncMu sync.Mutex // Sync を同時に実行しない（起動時の同期と !glossary add が重なる場合など）
+
+	mu      sync.RWMutex
+	entries map[string]string // 日本語 → 英語
+	ids     map[string]string // "JA>EN" → glossary_id
+}
+
+// NewGlossary は用語集ファイルを読み込みます（ファイルがなければ空で開始）。
+// DeepL 側への反映は Sync を呼ぶまで行いません
+func NewGlossary(client *deepl.Client, path string) (*Glossary, error) {
+	g := &Glossary{
+		client:  client,
+		path:    path,
+		entries: map[string]string{},
+		ids:     map[string]string{},
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return g, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = 2
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	for i, rec := range records {
+		if i == 0 && rec[0] == "ja" {
+			continue // ヘッダー行
+		}
+		g.entries[strings.TrimSpace(rec[0])] = strings.TrimSpace(rec[1])
+	}
+	return g, nil
+}
+
+// Len は登録済みの用語数を返します
+func (g *Glossary) Len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.entries)
+}
+
+// Set は用語を追加・更新し、ファイル保存と DeepL への反映を行います
+func (g *Glossary) Set(ja, en string) error {
+	ja, en = strings.TrimSpace(ja), strings.TrimSpace(en)
+	if ja == "" || en == "" || strings.ContainsAny(ja+en, "\t\r\n") {
+		return fmt.Errorf("glossary: invalid entry")
+	}
+	g.mu.Lock()
+	g.entries[ja] = en
+	err := g.save()
+	g.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return g.Sync()
+}
+
+// Delete は用語を削除し、ファイル保存と DeepL への反映を行います
+func (g *Glossary) Delete(ja string) error {
+	g.mu.Lock()
+	if _, ok := g.entries[ja]; !ok {
+		g.mu.Unlock()
+		return fmt.Errorf("glossary: %s is not registered", ja)
+	}
+	delete(g.entries, ja)
+	err := g.save()
+	g.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return g.Sync()
+}
+
+// save は用語集をCSVへ書き戻します（ロック済みで呼ぶ）
+func (g *Glossary) save() error {
+	keys := make([]string, 0, len(g.entries))
+	for k := range g.entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	tmp := g.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Write([]string{"ja", "en"})
+	for _, k := range keys {
+		w.Write([]string{k, g.entries[k]})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, g.path)
+}
+
+// Sync は現在の内容で JA→EN / EN→JA の用語集を作り直し、DeepL 上の古い bot 用の用語集を削除します。
+// 作成に成功するまでは古い用語集を使い続けます
+func (g *Glossary) Sync() error {
+	g.syncMu.Lock()
+	defer g.syncMu.Unlock()
+
+	g.mu.RLock()
+	jaEn := make([]string, 0, len(g.entries))
+	enJa := make([]string, 0, len(g.entries))
+	seenEn := map[string]bool{}
+	for ja, en := range g.entries {
+		jaEn = append(jaEn, ja+"\t"+en)
+		// 逆方向は英語側が重複すると DeepL がエラーにするので最初の1つだけ
+		if !seenEn[strings.ToLower(en)] {
+			seenEn[strings.ToLower(en)] = true
+			enJa = append(enJa, en+"\t"+ja)
+		}
+	}
+	g.mu.RUnlock()
+	sort.Strings(jaEn)
+	sort.Strings(enJa)
+
+	ids := map[string]string{}
+	if len(jaEn) > 0 {
+		id, err := g.create("JA", "EN", jaEn)
+		if err != nil {
+			return err
+		}
+		ids["JA>EN"] = id
+		if id, err = g.create("EN", "JA", enJa); err != nil {
+			// 片方だけ新しくならないよう、作成済みの分は消して古い用語集のままにする
+			g.client.DeleteGlossary(ids["JA>EN"])
+			return err
+		}
+		ids["EN>JA"] = id
+	}
+
+	g.mu.Lock()
+	g.ids = ids
+	g.mu.Unlock()
+	return g.deleteRemote(ids)
+}
+
+// deleteRemote は DeepL 上にある bot 用の用語集のうち、keep に含まれないものを削除します
+func (g *Glossary) deleteRemote(keep map[string]string) error {
+	list, err := g.client.ListGlossaries()
+	if err != nil {
+		return fmt.Errorf("glossary list: %w", err)
+	}
+	current := map[string]bool{}
+	for _, id := range keep {
+		current[id] = true
+	}
+	for _, gl := range list {
+		if !strings.HasPrefix(gl.Name, glossaryName) || current[gl.GlossaryID] {
+			continue
+		}
+		if err := g.client.DeleteGlossary(gl.GlossaryID); err != nil {
+			return fmt.Errorf("glossary delete %s: %w", gl.GlossaryID, err)
+		}
+	}
+	return nil
+}
+
+// create は用語集を1つ作成して glossary_id を返します
+func (g *Glossary) create(source, target string, tsv []string) (string, error) {
+	name := fmt.Sprintf("%s-%s-%s", glossaryName, strings.ToLower(source), strings.ToLower(target))
+	created, err := g.client.CreateGlossary(name, source, target, tsv)
+	if err != nil {
+		return "", fmt.Errorf("glossary create %s>%s: %w", source, target, err)
+	}
+	return created.GlossaryID, nil
+}
+
+// IDFor は翻訳に使う glossary_id と、その場合に指定すべき翻訳元言語を返します。
+// 用語集は翻訳元の言語指定が必須なので、langdetect で判定した言語（lang）が JA / EN の場合だけ使います
+//   - EN へ翻訳: 日本語なら JA→EN（中国語など漢字だけの他言語には使わない）
+//   - JA へ翻訳: 英語なら EN→JA
+func (g *Glossary) IDFor(lang, targetLang string) (id, sourceLang string) {
+	switch strings.ToUpper(targetLang) {
+	case "EN", "EN-US", "EN-GB":
+		if lang == "ja" {
+			sourceLang = "JA"
+		}
+	case "JA":
+		if lang == "en" {
+			sourceLang = "EN"
+		}
+	}
+	if sourceLang == "" {
+		return "", ""
+	}
+	pair := "JA>EN"
+	if sourceLang == "EN" {
+		pair = "EN>JA"
+	}
+	g.mu.RLock()
+	id = g.ids[pair]
+	g.mu.RUnlock()
+	if id == "" {
+		return "", ""
+	}
+	return id, sourceLang
+}
@@ -0,0 +1,66 @@
+package translate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Libre はセルフホストの LibreTranslate 互換サーバーを使う Translator です
+type Libre struct {
+	baseURL string
+	apiKey  string
+	client  *resty.Client
+}
+
+// NewLibre は LibreTranslate 用の Translator を作成します
+func NewLibre(baseURL, apiKey string) *Libre {
+	return &Libre{baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey, client: resty.New()}
+}
+
+// Name はバックエンド名を返します
+func (l *Libre) Name() string { return "libre" }
+
+type libreResponse struct {
+	TranslatedText   string `json:"translatedText"`
+	DetectedLanguage struct {
+		Confidence float64 `json:"confidence"`
+		Language   string  `json:"language"`
+	} `json:"detectedLanguage"`
+	Error string `json:"error"`
+}
+
+// Translate は POST /translate で翻訳します（翻訳元は自動判定）
+func (l *Libre) Translate(text, targetLang string) (*Result, error) {
+	body := map[string]string{
+		"q":      text,
+		"source": "auto",
+		"target": libreLang(targetLang),
+		"format": "html", // <x id="N"/>（エモートなどの目印）を訳文に残す
+	}
+	if l.apiKey != "" {
+		body["api_key"] = l.apiKey
+	}
+	var r libreResponse
+	resp, err := l.client.R().ForceContentType("application/json").SetBody(body).SetResult(&r).SetError(&r).Post(l.baseURL + "/translate")
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("libre: %s %s", resp.Status(), r.Error)
+	}
+	if r.TranslatedText == "" {
+		return nil, nil
+	}
+	return &Result{Text: r.TranslatedText, SourceLang: strings.ToUpper(r.DetectedLanguage.Language)}, nil
+}
+
+// libreLang は DeepL 形式の言語コード（"EN-US" など）を LibreTranslate 形式（"en"）に変換します
+func libreLang(lang string) string {
+	lang = strings.ToLower(lang)
+	if i := strings.Index(lang, "-"); i > 0 && lang != "zh-hant" {
+		lang = lang[:i]
+	}
+	return lang
+}
@@ -0,0 +1,118 @@
+package translate
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/k-p5w/go-marybot/internal/deepl"
+	"github.com/k-p5w/go-marybot/internal/quota"
+)
+
+// Result は翻訳結果です
+type Result struct {
+	Text       string `json:"text"`       // 翻訳後のテキスト
+	SourceLang string `json:"sourceLang"` // 検出された翻訳元の言語コード（大文字、不明なら空）
+}
+
+// Translator は翻訳バックエンドの共通インターフェースです
+type Translator interface {
+	// Name はログ表示用のバックエンド名を返します
+	Name() string
+	// Translate は text を targetLang（"JA" / "EN" など DeepL 形式の言語コード）に翻訳します。
+	// text は XML エスケープ済みで、<x id="N"/> のタグ（エモートやURLの目印）は訳文にそのまま残すこと。
+	// 訳せなかった場合は (nil, nil) を返すことがあります
+	Translate(text, targetLang string) (*Result, error)
+}
+
+// ErrNoTranslation は辞書に該当がないなど、訳文を返せなかったことを示します
+var ErrNoTranslation = errors.New("translate: no translation")
+
+// ErrBudgetExceeded は DeepL の1日の予算を超えたため DeepL に送らなかったことを示します
+var ErrBudgetExceeded = errors.New("translate: deepl daily budget exceeded")
+
+// Config は各バックエンドの設定です
+type Config struct {
+	DeepL          *deepl.Client  // DeepL のクライアント（nil なら deepl は使えない）
+	Glossary       *Glossary      // DeepL で使う用語集（nil なら使わない）
+	Quota          *quota.Manager // DeepL の1日の予算（nil なら制限しない）
+	LibreURL       string         // LibreTranslate 互換サーバーのURL（例: http://localhost:5000）
+	LibreAPIKey    string
+	DictionaryFile string // オフライン辞書のJSONファイル
+}
+
+// New は "deepl,libre,dict" のようなバックエンド名の並びから Translator を作ります。
+// 複数指定した場合は先頭から順に試し、失敗したら次へフォールバックします
+func New(backends string, cfg Config) (Translator, error) {
+	var chain Chain
+	for _, name := range strings.Split(backends, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "":
+			continue
+		case "deepl":
+			if cfg.DeepL == nil {
+				return nil, fmt.Errorf("translate: deepl needs DEEPL_API_KEY")
+			}
+			d := NewDeepL(cfg.DeepL)
+			d.Glossary = cfg.Glossary
+			d.Quota = cfg.Quota
+			chain = append(chain, d)
+		case "libre", "libretranslate":
+			if cfg.LibreURL == "" {
+				return nil, fmt.Errorf("translate: libre needs LIBRETRANSLATE_URL")
+			}
+			chain = append(chain, NewLibre(cfg.LibreURL, cfg.LibreAPIKey))
+		case "dict", "dictionary":
+			d, err := LoadDictionary(cfg.DictionaryFile)
+			if err != nil {
+				return nil, err
+			}
+			chain = append(chain, d)
+		default:
+			return nil, fmt.Errorf("translate: unknown backend %q", name)
+		}
+	}
+	switch len(chain) {
+	case 0:
+		return nil, fmt.Errorf("translate: no backend configured")
+	case 1:
+		return chain[0], nil
+	}
+	return chain, nil
+}
+
+// Chain は複数のバックエンドを順番に試す Translator です
+type Chain []Translator
+
+// Name はバックエンド名を "deepl>dict" のようにつないで返します
+func (c Chain) Name() string {
+	names := make([]string, len(c))
+	for i, t := range c {
+		names[i] = t.Name()
+	}
+	return strings.Join(names, ">")
+}
+
+// Translate は先頭のバックエンドから順に試し、最初に得られた訳文を返します。
+// すべて失敗した場合は各バックエンドのエラーをまとめて返すので、
+// errors.Is(err, deepl.ErrQuotaExceeded) のように原因を調べられます
+func (c Chain) Translate(text, targetLang string) (*Result, error) {
+	var errs []error
+	for _, t := range c {
+		res, err := t.Translate(text, targetLang)
+		if err == nil && res != nil {
+			return res, nil
+		}
+		if err != nil && !errors.Is(err, ErrNoTranslation) {
+			log.Printf("translate: %s failed, trying next: %v", t.Name(), err)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil, ErrNoTranslation
+	}
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,113 @@
+package twitchauth
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// DefaultTokenURL は Twitch の OAuth トークン発行エンドポイントです
+const DefaultTokenURL = "https://id.twitch.tv/oauth2/token"
+
+// refreshMargin は有効期限のどれくらい前に取り直すかです
+const refreshMargin = 5 * time.Minute
+
+// tokenResponse は /oauth2/token の応答です
+type tokenResponse struct {
+	AccessToken  string   `json:"access_token"`
+	RefreshToken string   `json:"refresh_token"`
+	ExpiresIn    int      `json:"expires_in"`
+	Scope        []string `json:"scope"`
+	TokenType    string   `json:"token_type"`
+	Message      string   `json:"message"` // エラー時の説明
+}
+
+// AppTokenSource は client credentials で取得したアプリアクセストークンを共有します。
+// トークンはキャッシュし、有効期限が近づいたら取り直します。複数の goroutine から同時に使えます
+type AppTokenSource struct {
+	clientID     string
+	clientSecret string
+	TokenURL     string // テスト用に差し替え可能
+
+	http   *resty.Client
+	client *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// NewAppTokenSource はアプリアクセストークンの取得元を作成します
+func NewAppTokenSource(clientID, clientSecret string) *AppTokenSource {
+	return &AppTokenSource{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		TokenURL:     DefaultTokenURL,
+		http:         resty.New().SetTimeout(15 * time.Second),
+		client:       &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ClientID は Helix の Client-ID ヘッダーに使う値を返します
+func (s *AppTokenSource) ClientID() string { return s.clientID }
+
+// Token は有効なアクセストークンを返します。期限切れ間近なら取り直します
+func (s *AppTokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Until(s.expiry) > refreshMargin {
+		return s.token, nil
+	}
+	var out tokenResponse
+	resp, err := s.http.R().SetFormData(map[string]string{
+		"client_id":     s.clientID,
+		"client_secret": s.clientSecret,
+		"grant_type":    "client_credentials",
+	}).SetResult(&out).SetError(&out).ForceContentType("application/json").Post(s.TokenURL)
+	if err != nil {
+		return "", err
+	}
+	if resp.IsError() || out.AccessToken == "" {
+		return "", fmt.Errorf("twitchauth: app token: %s %s", resp.Status(), out.Message)
+	}
+	s.token = out.AccessToken
+	s.expiry = time.Now().Add(time.Duration(out.ExpiresIn) * time.Second)
+	return s.token, nil
+}
+
+// Invalidate は token が使えなかった（401）場合に呼び、次の Token で取り直させます。
+// 別の goroutine が既に取り直していれば何もしません
+func (s *AppTokenSource) Invalidate(token string) {
+	s.mu.Lock()
+	if s.token == token {
+		s.token = ""
+	}
+	s.mu.Unlock()
+}
+
+// Do は Client-ID と Authorization ヘッダーを付けてリクエストを送ります。
+// 401 が返ったらトークンを取り直して1度だけ送り直します（本文のない GET などで使う）。
+// helix.Client の認証にそのまま渡せます
+func (s *AppTokenSource) Do(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		token, err := s.Token()
+		if err != nil {
+			return nil, err
+		}
+		r := req.Clone(req.Context())
+		r.Header.Set("Client-ID", s.clientID)
+		r.Header.Set("Authorization", "Bearer "+token)
+		resp, err := s.client.Do(r)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusUnauthorized || attempt > 0 {
+			return resp, nil
+		}
+		resp.Body.Close()
+		s.Invalidate(token)
+	}
+}
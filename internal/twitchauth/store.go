@@ -0,0 +1,98 @@
+package twitchauth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// UserToken はユーザーアクセストークンとリフレッシュトークンです
+type UserToken struct {
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken"`
+	Expiry       time.Time `json:"expiry"`
+	Scopes       []string  `json:"scopes"`
+	Login        string    `json:"login"`  // トークンの持ち主のログイン名
+	UserID       string    `json:"userID"` // トークンの持ち主のユーザーID
+}
+
+// tokenStore はトークンをローカルの鍵で暗号化（AES-256-GCM）してファイルに保存します
+type tokenStore struct {
+	path string
+	aead cipher.AEAD
+}
+
+// newTokenStore は keyPath の鍵を読み込みます。鍵ファイルがなければ乱数で作成します（パーミッション 0600）
+func newTokenStore(path, keyPath string) (*tokenStore, error) {
+	key, err := os.ReadFile(keyPath)
+	if os.IsNotExist(err) {
+		key = make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(keyPath, key, 0600); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("twitchauth: %s must be 32 bytes", keyPath)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenStore{path: path, aead: aead}, nil
+}
+
+// load は保存済みのトークンを読み込みます。ファイルがなければ nil を返します
+func (s *tokenStore) load() (*UserToken, error) {
+	b, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	n := s.aead.NonceSize()
+	if len(b) < n {
+		return nil, errors.New("twitchauth: token file is broken")
+	}
+	plain, err := s.aead.Open(nil, b[:n], b[n:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("twitchauth: %s: %v（鍵ファイルが変わった可能性があります）", s.path, err)
+	}
+	var t UserToken
+	if err := json.Unmarshal(plain, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// save はトークンを暗号化して書き込みます（先頭にナンス）
+func (s *tokenStore) save(t *UserToken) error {
+	plain, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, s.aead.Seal(nonce, nonce, plain, nil), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
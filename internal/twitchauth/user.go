@@ -0,0 +1,279 @@
+package twitchauth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Twitch の OAuth エンドポイント
+const (
+	DefaultAuthorizeURL = "https://id.twitch.tv/oauth2/authorize"
+	DefaultValidateURL  = "https://id.twitch.tv/oauth2/validate"
+)
+
+// ErrNoToken はまだ /auth/login で認証していない（またはリフレッシュに失敗した）ことを示します
+var ErrNoToken = errors.New("twitchauth: not authorized (open /auth/login)")
+
+// UserConfig はユーザーアクセストークン（authorization code フロー）の設定です
+type UserConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string   // 例: https://example.com/auth/callback（Twitch の開発者コンソールに登録したもの）
+	Scopes       []string // 例: chat:read, chat:edit
+	Login        string   // 認証を許可するアカウント（bot のログイン名）。空なら誰でも
+	TokenFile    string   // 暗号化したトークンの保存先
+	KeyFile      string   // 暗号化の鍵（なければ作成）
+}
+
+// UserAuth は bot アカウントのユーザーアクセストークンを管理します。
+// /auth/login から Twitch の認可画面へ飛ばし、/auth/callback で受け取ったトークンを暗号化して保存します。
+// 有効期限が近づいたらリフレッシュトークンで自動的に更新し、OnUpdate で新しいトークンを知らせます
+type UserAuth struct {
+	cfg          UserConfig
+	AuthorizeURL string // テスト用に差し替え可能
+	TokenURL     string
+	ValidateURL  string
+
+	// OnUpdate はログインやリフレッシュでトークンが変わるたびに呼ばれます（IRC の再接続などに使う）
+	OnUpdate func(accessToken string)
+
+	store *tokenStore
+	http  *resty.Client
+
+	mu     sync.Mutex
+	token  *UserToken
+	states map[string]time.Time // 発行済みの state（CSRF 対策）→ 期限
+	wake   chan struct{}        // 自動更新の待ち時間を計算し直させる
+}
+
+// NewUserAuth は保存済みのトークンがあれば読み込んで UserAuth を作成します
+func NewUserAuth(cfg UserConfig) (*UserAuth, error) {
+	store, err := newTokenStore(cfg.TokenFile, cfg.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	token, err := store.load()
+	if err != nil {
+		return nil, err
+	}
+	return &UserAuth{
+		cfg:          cfg,
+		AuthorizeURL: DefaultAuthorizeURL,
+		TokenURL:     DefaultTokenURL,
+		ValidateURL:  DefaultValidateURL,
+		store:        store,
+		http:         resty.New().SetTimeout(15 * time.Second),
+		token:        token,
+		states:       map[string]time.Time{},
+		wake:         make(chan struct{}, 1),
+	}, nil
+}
+
+// Token は有効なアクセストークンを返します。期限切れ間近ならリフレッシュします
+func (a *UserAuth) Token() (string, error) {
+	a.mu.Lock()
+	t := a.token
+	a.mu.Unlock()
+	if t == nil {
+		return "", ErrNoToken
+	}
+	if time.Until(t.Expiry) > refreshMargin {
+		return t.AccessToken, nil
+	}
+	if err := a.Refresh(); err != nil {
+		return "", err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.token.AccessToken, nil
+}
+
+// LoginHandler は Twitch の認可画面へリダイレクトします（/auth/login）
+func (a *UserAuth) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	state := hex.EncodeToString(b)
+	now := time.Now()
+	a.mu.Lock()
+	for s, exp := range a.states {
+		if now.After(exp) {
+			delete(a.states, s)
+		}
+	}
+	a.states[state] = now.Add(10 * time.Minute)
+	a.mu.Unlock()
+
+	q := url.Values{
+		"client_id":     {a.cfg.ClientID},
+		"redirect_uri":  {a.cfg.RedirectURI},
+		"response_type": {"code"},
+		"scope":         {strings.Join(a.cfg.Scopes, " ")},
+		"state":         {state},
+		"force_verify":  {"true"}, // 別アカウントでログインし直せるように
+	}
+	http.Redirect(w, r, a.AuthorizeURL+"?"+q.Encode(), http.StatusFound)
+}
+
+// CallbackHandler は認可コードをトークンに交換して保存します（/auth/callback）
+func (a *UserAuth) CallbackHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	state := q.Get("state")
+	a.mu.Lock()
+	exp, ok := a.states[state]
+	delete(a.states, state)
+	a.mu.Unlock()
+	if !ok || time.Now().After(exp) {
+		http.Error(w, "invalid state (もう一度 /auth/login からやり直してください)", http.StatusBadRequest)
+		return
+	}
+	if e := q.Get("error"); e != "" {
+		http.Error(w, "authorization denied: "+e, http.StatusBadRequest)
+		return
+	}
+
+	token, err := a.exchange(map[string]string{
+		"grant_type":   "authorization_code",
+		"code":         q.Get("code"),
+		"redirect_uri": a.cfg.RedirectURI,
+	})
+	if err == nil {
+		err = a.validate(token)
+	}
+	if err == nil && a.cfg.Login != "" && !strings.EqualFold(token.Login, a.cfg.Login) {
+		// bot 以外のアカウントのトークンで上書きされないようにする
+		err = fmt.Errorf("%s ではなく %s でログインしてください", token.Login, a.cfg.Login)
+	}
+	if err == nil {
+		err = a.set(token)
+	}
+	if err != nil {
+		log.Printf("twitchauth: callback: %v", err)
+		http.Error(w, "authorization failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	log.Printf("twitchauth: authorized as %s", token.Login)
+	fmt.Fprintf(w, "✅ %s で認証しました。このページは閉じて大丈夫です。", token.Login)
+}
+
+// Refresh はリフレッシュトークンでアクセストークンを更新します
+func (a *UserAuth) Refresh() error {
+	a.mu.Lock()
+	old := a.token
+	a.mu.Unlock()
+	if old == nil || old.RefreshToken == "" {
+		return ErrNoToken
+	}
+	token, err := a.exchange(map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": old.RefreshToken,
+	})
+	if err != nil {
+		return err
+	}
+	token.Login, token.UserID = old.Login, old.UserID
+	return a.set(token)
+}
+
+// Run はトークンの有効期限が近づくたびにリフレッシュします（goroutine で起動）
+func (a *UserAuth) Run() {
+	for {
+		wait := time.Minute
+		a.mu.Lock()
+		if a.token != nil {
+			wait = time.Until(a.token.Expiry) - refreshMargin
+		}
+		a.mu.Unlock()
+
+		if wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-a.wake:
+				continue
+			}
+		}
+		a.mu.Lock()
+		hasToken := a.token != nil
+		a.mu.Unlock()
+		if !hasToken {
+			continue
+		}
+		if err := a.Refresh(); err != nil {
+			log.Printf("twitchauth: refresh failed: %v", err)
+			// 失敗したら少し待ってから再試行
+			select {
+			case <-time.After(time.Minute):
+			case <-a.wake:
+			}
+		}
+	}
+}
+
+// set は新しいトークンを保存し、OnUpdate で知らせます
+func (a *UserAuth) set(t *UserToken) error {
+	if err := a.store.save(t); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.token = t
+	a.mu.Unlock()
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+	if a.OnUpdate != nil {
+		a.OnUpdate(t.AccessToken)
+	}
+	return nil
+}
+
+// exchange は /oauth2/token でトークンを取得します（認可コードまたはリフレッシュトークン）
+func (a *UserAuth) exchange(params map[string]string) (*UserToken, error) {
+	params["client_id"] = a.cfg.ClientID
+	params["client_secret"] = a.cfg.ClientSecret
+	var out tokenResponse
+	resp, err := a.http.R().SetFormData(params).SetResult(&out).SetError(&out).
+		ForceContentType("application/json").Post(a.TokenURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() || out.AccessToken == "" {
+		return nil, fmt.Errorf("twitchauth: %s: %s %s", params["grant_type"], resp.Status(), out.Message)
+	}
+	return &UserToken{
+		AccessToken:  out.AccessToken,
+		RefreshToken: out.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(out.ExpiresIn) * time.Second),
+		Scopes:       out.Scope,
+	}, nil
+}
+
+// validate は /oauth2/validate でトークンの持ち主を確認します
+func (a *UserAuth) validate(t *UserToken) error {
+	var out struct {
+		Login  string `json:"login"`
+		UserID string `json:"user_id"`
+	}
+	resp, err := a.http.R().SetHeader("Authorization", "OAuth "+t.AccessToken).
+		SetResult(&out).ForceContentType("application/json").Get(a.ValidateURL)
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("twitchauth: validate: %s", resp.Status())
+	}
+	t.Login, t.UserID = out.Login, out.UserID
+	return nil
+}
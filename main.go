@@ -2,24 +2,36 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gempir/go-twitch-irc/v4"
-	"github.com/go-resty/resty/v2"
 	"github.com/joho/godotenv"
 	"github.com/k-p5w/go-marybot/internal/bandainamco"
+	"github.com/k-p5w/go-marybot/internal/command"
+	"github.com/k-p5w/go-marybot/internal/deepl"
+	"github.com/k-p5w/go-marybot/internal/eventsub"
+	"github.com/k-p5w/go-marybot/internal/helix"
+	"github.com/k-p5w/go-marybot/internal/langdetect"
+	"github.com/k-p5w/go-marybot/internal/quota"
+	"github.com/k-p5w/go-marybot/internal/tokenize"
+	"github.com/k-p5w/go-marybot/internal/translate"
+	"github.com/k-p5w/go-marybot/internal/twitchauth"
 )
 
-var UsedMsg = "unknown"
-var reJapanese = regexp.MustCompile(`[\p{Hiragana}\p{Katakana}]`)
-
 // バージョン情報の定義
 const BotVersion = "!コマンド追加 e.g.!help" // アメイジア東対応 & HELP追加版
 
@@ -34,13 +46,6 @@ type Config struct {
 	DeepLAPIKey  string `json:"deepLAPIKey"`
 }
 
-type TwitchStreamInfo struct {
-	Data []struct {
-		Title    string `json:"title"`
-		GameName string `json:"game_name"`
-	} `json:"data"`
-}
-
 func main() {
 	_ = godotenv.Load()
 	myURL := os.Getenv("MY_URL")
@@ -51,13 +56,153 @@ func main() {
 	joinChannelName := os.Getenv("CHANNEL_NAME")
 	deepLApiKey := os.Getenv("DEEPL_API_KEY")
 
-	if botUsername == "" || oauthToken == "" || joinChannelName == "" || deepLApiKey == "" {
-		log.Fatal("❌ 必須設定(BOT_NAME, OAUTH_TOKEN, CHANNEL_NAME, DEEPL_API_KEY)が足りません。")
+	// OAUTH_REDIRECT_URI を設定すると、OAUTH_TOKEN の代わりに /auth/login で認証したトークンを使う
+	if botUsername == "" || (oauthToken == "" && os.Getenv("OAUTH_REDIRECT_URI") == "") || joinChannelName == "" {
+		log.Fatal("❌ 必須設定(BOT_NAME, OAUTH_TOKEN, CHANNEL_NAME)が足りません。")
+	}
+
+	// 翻訳バックエンド（例: TRANSLATOR="deepl,libre,dict" なら DeepL 失敗時に順にフォールバック）
+	backends := os.Getenv("TRANSLATOR")
+	if backends == "" {
+		backends = "deepl"
+	}
+	dictionaryFile := os.Getenv("DICTIONARY_FILE")
+	if dictionaryFile == "" {
+		dictionaryFile = "dictionary.json"
+	}
+	// DeepL のエンドポイントはキー末尾(:fx)で free/pro を自動判定。DEEPL_PLAN / DEEPL_API_URL で上書き可
+	var deepLClient *deepl.Client
+	if deepLApiKey != "" {
+		deepLClient = deepl.New(deepLApiKey, deepl.BaseURL(deepLApiKey, os.Getenv("DEEPL_PLAN"), os.Getenv("DEEPL_API_URL")))
+	}
+
+	// DeepL 用語集（GLOSSARY_FILE の ja,en を JA↔EN の翻訳に使う）
+	var glossary *translate.Glossary
+	if deepLClient != nil {
+		glossaryFile := os.Getenv("GLOSSARY_FILE")
+		if glossaryFile == "" {
+			glossaryFile = "glossary.csv"
+		}
+		var err error
+		glossary, err = translate.NewGlossary(deepLClient, glossaryFile)
+		if err != nil {
+			log.Fatal("❌ 用語集の読み込みに失敗: ", err)
+		}
+		go func() {
+			if err := glossary.Sync(); err != nil {
+				log.Printf("glossary sync failed: %v", err)
+			}
+		}()
+	}
+
+	// DeepL の残量管理（1日の予算を超えたら段階的に制限する。キャッシュや他のバックエンドは制限しない）
+	var quotaMgr *quota.Manager
+	if deepLClient != nil {
+		billingDay, _ := strconv.Atoi(os.Getenv("DEEPL_BILLING_DAY"))
+		longChars, _ := strconv.Atoi(os.Getenv("QUOTA_LONG_CHARS"))
+		quotaMgr = quota.New(billingDay, longChars)
+	}
+
+	translator, err := translate.New(backends, translate.Config{
+		DeepL:          deepLClient,
+		Glossary:       glossary,
+		Quota:          quotaMgr,
+		LibreURL:       os.Getenv("LIBRETRANSLATE_URL"),
+		LibreAPIKey:    os.Getenv("LIBRETRANSLATE_API_KEY"),
+		DictionaryFile: dictionaryFile,
+	})
+	if err != nil {
+		log.Fatal("❌ 翻訳設定エラー: ", err)
+	}
+
+	// 翻訳キャッシュ（TRANSLATE_CACHE_FILE を指定すると再起動後も残る）
+	cacheSize, _ := strconv.Atoi(os.Getenv("TRANSLATE_CACHE_SIZE"))
+	translateCache, err := translate.NewCache(translator, cacheSize, os.Getenv("TRANSLATE_CACHE_FILE"))
+	if err != nil {
+		log.Fatal("❌ 翻訳キャッシュの読み込みに失敗: ", err)
+	}
+	translator = translateCache
+	go func() {
+		for range time.Tick(5 * time.Minute) {
+			if err := translateCache.Save(); err != nil {
+				log.Printf("translate cache save failed: %v", err)
+			}
+		}
+	}()
+	log.Printf("Translator: %s", translator.Name())
+
+	// 翻訳元言語 → 翻訳先言語の対応表（例: TRANSLATE_ROUTES="ja=EN,ko=JA|EN,*=JA"）
+	routes, err := langdetect.ParseRoutes(os.Getenv("TRANSLATE_ROUTES"))
+	if err != nil {
+		log.Fatal("❌ 翻訳ルート設定エラー: ", err)
+	}
+	// 国際的なチャット向け: チャンネルで使う言語（例: CHANNEL_LANGS="EN,JA,KO"）
+	// 設定すると、各メッセージを元の言語以外のすべての言語へ翻訳する
+	var channelLangs []string
+	for _, l := range strings.Split(os.Getenv("CHANNEL_LANGS"), ",") {
+		if l = strings.ToUpper(strings.TrimSpace(l)); l != "" {
+			channelLangs = append(channelLangs, l)
+		}
+	}
+	// BTTV/7TV など Twitch のタグに載らないエモート名（例: EXTRA_EMOTES="KEKW,catJAM"）
+	extraEmotes := map[string]bool{}
+	for _, e := range strings.Split(os.Getenv("EXTRA_EMOTES"), ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			extraEmotes[e] = true
+		}
 	}
+	// 翻訳の投稿方法: "reply"（既定）なら元メッセージへのリプライ、"by" なら従来の【by ユーザー】形式
+	replyMode := os.Getenv("TRANSLATE_REPLY_MODE") != "by"
+	// TRANSLATE_OUTPUT=combined なら複数言語の翻訳を1メッセージにまとめる（既定は言語ごとに投稿）
+	combinedOutput := os.Getenv("TRANSLATE_OUTPUT") == "combined"
 
 	// オプション設定
 	clientID := os.Getenv("CLIENT_ID")
 	clientSecret := os.Getenv("CLIENT_SECRET")
+	// Helix API（アプリアクセストークンは期限切れ前に自動で取り直す。HELIX_URL で接続先を変更可）
+	var helixClient *helix.Client
+	if clientID != "" && clientSecret != "" {
+		helixClient = helix.New(twitchauth.NewAppTokenSource(clientID, clientSecret))
+		if u := os.Getenv("HELIX_URL"); u != "" {
+			helixClient.BaseURL = u
+		}
+	}
+
+	// 翻訳モード（!tr on|off|auto|ja-only|en-only、再起動しても保持）
+	// auto は直近 TR_AUTO_MINUTES 分（既定10分）に日本語以外の発言があったときだけ翻訳する
+	autoMinutes, _ := strconv.Atoi(os.Getenv("TR_AUTO_MINUTES"))
+	if autoMinutes <= 0 {
+		autoMinutes = 10
+	}
+	stateFile := os.Getenv("STATE_FILE")
+	if stateFile == "" {
+		stateFile = defaultStateFile
+	}
+	trMode, err := loadTranslateMode(stateFile, time.Duration(autoMinutes)*time.Minute)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// DeepL 使用量（DEEPL_USAGE_INTERVAL ごとに取得し直す。例: "5m"、既定5分）
+	var usage *deepLUsage
+	if deepLClient != nil {
+		usage = &deepLUsage{fetch: func() (int, int, error) {
+			return getUsage(deepLClient)
+		}}
+	}
+
+	// チャット用トークンの OAuth 認証（OAUTH_REDIRECT_URI を設定した場合のみ）
+	// 認証済みのトークンは暗号化して保存し、期限が近づいたら自動で更新する
+	chatLogin, err := newChatAuth(clientID, clientSecret, botUsername)
+	if err != nil {
+		log.Fatal("❌ チャット用の OAuth 設定エラー: ", err)
+	}
+	if chatLogin != nil {
+		if token := chatLogin.ircToken(); token != "" {
+			oauthToken = token
+		}
+		go chatLogin.auth.Run()
+	}
 
 	// --- 2. Webサーバー設定 ---
 	port := os.Getenv("PORT")
@@ -66,8 +211,12 @@ func main() {
 	}
 	go func() {
 		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-			fmt.Fprintf(w, "Bot is running! DeepL Usage: %s", UsedMsg)
+			fmt.Fprintf(w, "Bot is running! DeepL Usage: %s %s | Translate: %s", usage, usage.StreamSummary(), trMode.Mode())
 		})
+		if chatLogin != nil {
+			http.HandleFunc("/auth/login", chatLogin.auth.LoginHandler)
+			http.HandleFunc("/auth/callback", chatLogin.auth.CallbackHandler)
+		}
 		addr := ":" + port
 		if os.Getenv("PORT") == "" {
 			addr = "localhost:" + port
@@ -77,134 +226,384 @@ func main() {
 	}()
 
 	client := twitch.NewClient(botUsername, oauthToken)
-	charUsrs := map[string]int{}
+
+	// 初投稿ユーザーの記録（再起動しても [新] がリセットされないようにファイル保存）
+	chatterFile := os.Getenv("CHATTER_FILE")
+	if chatterFile == "" {
+		chatterFile = defaultChatterFile
+	}
+	chatters, err := loadChatterStore(chatterFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go chatters.autoSave(time.Minute)
+	// TRANSLATE_MIN_CHARS より短い発言は翻訳しない（!translate で「常に翻訳」にした人は除く）
+	translateMinChars, _ := strconv.Atoi(os.Getenv("TRANSLATE_MIN_CHARS"))
+	// 言語判定の確からしさが LANG_MIN_CONFIDENCE（既定0.5）未満の発言は !tr auto の判定に使わない（翻訳はする）
+	langMinConfidence, err := strconv.ParseFloat(os.Getenv("LANG_MIN_CONFIDENCE"), 64)
+	if err != nil {
+		langMinConfidence = 0.5
+	}
+	// FIRST_STREAM_TAG を設定すると、今回の配信で初めての発言にもタグを付ける（例: "[今日初]"）
+	firstStreamTag := os.Getenv("FIRST_STREAM_TAG")
+
+	// DeepL の残量切れ(456)をチャットで知らせたかどうか（連投しないため）
+	var quotaNoticeSent atomic.Bool
+	// 直前に取得した使用文字数（-1 なら未取得）。変化したら DeepL で翻訳できた（または請求期間が変わった）とみなす
+	var lastUsageCount atomic.Int64
+	lastUsageCount.Store(-1)
+
+	// DeepL の使用量を定期的に取得して予算を更新する
+	if usage != nil {
+		// 制限段階が変わるたびにチャットでモデレーターへ知らせる
+		quotaMgr.OnLevelChange = func(level quota.Level, st quota.Status) {
+			client.Say(joinChannelName, fmt.Sprintf("⚠ [mods] DeepL予算 %s → 翻訳モード: %s", st.Summary(), level))
+		}
+		usage.onUpdate = func(count, limit int, now time.Time) {
+			quotaMgr.Update(count, limit, now)
+			// 残りが少しあっても 456 は返るので、残量ではなく使用文字数の変化で判断する。
+			// 増えた（DeepL で翻訳できた）か減った（請求期間が変わった）ら、次の残量切れを再び知らせる
+			if prev := lastUsageCount.Swap(int64(count)); prev >= 0 && prev != int64(count) {
+				quotaNoticeSent.Store(false)
+			}
+		}
+
+		interval, err := time.ParseDuration(os.Getenv("DEEPL_USAGE_INTERVAL"))
+		if err != nil || interval <= 0 {
+			interval = 5 * time.Minute
+		}
+		go usage.run(interval)
+	}
+
+	// 配信状態の監視（Twitch API 設定がある場合のみ。STREAM_POLL_INTERVAL ごとに確認、既定1分）
+	var streams *streamWatcher
+	if helixClient != nil {
+		pollInterval, _ := time.ParseDuration(os.Getenv("STREAM_POLL_INTERVAL"))
+		streams = newStreamWatcher(func() (*helix.Stream, error) {
+			return getStreamInfo(joinChannelName, helixClient)
+		}, pollInterval)
+	}
+
+	// --- 全てのゲームで共通して使えるコマンド ---
+	// ゲーム別のコマンド（!syn など）は各パッケージの init() で command.Default に登録されます。
+	// FF14 などを追加する場合も internal/squareenix のようなパッケージ側で登録してください。
+	commands := command.Default
+	commands.Register(commands.HelpCommand())
+	commands.Register(&command.Command{
+		Name:        "status",
+		Description: "botの状態",
+		Usage:       "!status",
+		Cooldown:    15 * time.Second,
+		Handler: func(c *command.Context) string {
+			status := "⚙ bot-status | " + formatStatus(BotVersion, usage.String(), cacheStats(translateCache), calculateRemainingWeeks()) + " | 翻訳:" + trMode.Mode()
+			if s := usage.StreamSummary(); s != "" {
+				status += " | " + s
+			}
+			if quotaMgr != nil {
+				status += " | " + quotaMgr.Status().Summary()
+			}
+			return status + " for " + joinChannelName
+		},
+	})
+
+	// botMessage.json のカスタムコマンド（!cmd add/edit/del でモデレーターが編集可能）
+	botMessages, err := loadBotMessage(botMessageFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	botMessages.registerCustomCommands(commands, &templateVars{
+		channel: joinChannelName,
+		streamInfo: func() (*helix.Stream, error) {
+			return getStreamInfo(joinChannelName, helixClient)
+		},
+		deeplUsage: usage.String,
+	})
+
+	if glossary != nil {
+		registerGlossaryCommand(commands, glossary, translateCache)
+	}
+
+	// 翻訳メッセージの書式（botMessage.json の TranslationTemplates、未設定なら従来の形式）
+	outputTemplates, err := newTranslationTemplates(botMessages.data.TranslationTemplates, replyMode)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 翻訳の希望（!notranslate / !translate）
+	registerTranslatePrefCommands(commands, chatters)
+	// チャンネル全体の翻訳モード（!tr）
+	registerTranslateModeCommand(commands, trMode)
+
+	// 初チャットの挨拶（botMessage.json の OnUserJoinMessage / OnUserJoinMessageEN）
+	greetings := newGreeter(botMessages.data, botUsername)
+
+	// クールダウン設定（例: COMMAND_COOLDOWNS="syn=30/60,status=15"）
+	if err := commands.SetCooldowns(os.Getenv("COMMAND_COOLDOWNS")); err != nil {
+		log.Fatal(err)
+	}
+	// 必要権限の上書き（例: COMMAND_ROLES="status=mod"）
+	if err := commands.SetRoles(os.Getenv("COMMAND_ROLES")); err != nil {
+		log.Fatal(err)
+	}
+	// COOLDOWN_NOTICE=1 ならクールダウン中の呼び出しに1度だけリプライで通知（未設定なら黙って無視）
+	// ※ go-twitch-irc v4 には Whisper がないため、元メッセージへのリプライで代用します
+	if os.Getenv("COOLDOWN_NOTICE") == "1" {
+		commands.Notify = func(m twitch.PrivateMessage, text string) {
+			client.Reply(joinChannelName, m.ID, text)
+		}
+	}
 
 	// --- 3. メッセージ翻訳処理 ---
 	// このハンドラはユーザーがチャットに送信したメッセージを受け取ります。
-	// 日本語と英語を自動判定して相互翻訳し、翻訳済みメッセージをチャットに投稿します。
+	// 言語を自動判定して対応表の言語へ翻訳し、翻訳済みメッセージをチャットに投稿します。
 	client.OnPrivateMessage(func(message twitch.PrivateMessage) {
 
-		// 1. コマンドかどうか判定
-		if strings.HasPrefix(message.Message, "!") {
-			fullCmd := strings.TrimPrefix(message.Message, "!")
-			args := strings.Split(fullCmd, " ")
-			command := strings.ToLower(args[0])
-
-			// --- 全てのゲームで共通して使えるコマンド ---
-			switch command {
-			case "!", "help":
-				// 利用可能なコマンドを一覧表示
-				helpMsg := "📖 利用可能コマンド: !status (botの状態), !syn (シンデュアのMAP状況)"
-				client.Say(joinChannelName, helpMsg)
-				return
-			case "status":
-				statusMsg := "⚙ bot-status | " + formatStatus(BotVersion, UsedMsg, calculateRemainingWeeks()) + " for " + joinChannelName
-				client.Say(joinChannelName, statusMsg)
-				return
-			case "syn":
-				// どの配信中であっても、!syn と打たれれば即座に回答
-				msg := bandainamco.GetSynStatus(true) // 「今の状況」を返す関数
-				client.Say(joinChannelName, msg)
-				return
-			case "ff14":
-				// 同様に、FF14の情報をいつでも呼び出せる
-				// msg := squareenix.GetFF14Status()
-				// client.Say(joinChannelName, msg)
-				return
+		// 1. コマンドかどうか判定（登録済みコマンドならここで処理して終了）
+		if reply, handled := commands.Dispatch(message); handled {
+			if reply != "" {
+				client.Say(joinChannelName, reply)
 			}
+			return
 		}
 
 		// ボット自身のメッセージは処理しない（無限ループ防止）
 		if message.User.Name == botUsername {
 			return
 		}
+		// 配信していない間は翻訳しない（コマンドは使える）
+		if !streams.Live() {
+			return
+		}
 
-		// ステップ1: エモートを除去してテキストを整形
-		cleanMsg := message.Message
+		// ステップ1: エモート・@メンション・URL などを目印に置き換えて翻訳で壊れないようにする
+		var emoteSpans []tokenize.Span
 		for _, emote := range message.Emotes {
-			cleanMsg = strings.ReplaceAll(cleanMsg, emote.Name, "")
+			for _, pos := range emote.Positions {
+				emoteSpans = append(emoteSpans, tokenize.Span{Start: pos.Start, End: pos.End})
+			}
+		}
+		maskedMsg, tokens := tokenize.Protect(message.Message, emoteSpans, extraEmotes)
+		cleanMsg := tokenize.Plain(maskedMsg)
+		// 目印以外に本文がないメッセージ（エモートだけ等）はスキップ
+		if cleanMsg == "" {
+			return
+		}
+
+		// 言語判定（文字種と頻出語から推定）
+		detected := langdetect.Detect(cleanMsg)
+
+		// このチャンネルで初投稿のユーザーに [新] タグを付与し、設定があれば挨拶する
+		first := ""
+		firstEver, firstStream := chatters.Seen(message.User.ID, message.User.Name, time.Now())
+		if firstStream && firstStreamTag != "" {
+			first = firstStreamTag
+		}
+		if firstEver {
+			first = "[新]"
+			name := message.User.DisplayName
+			if name == "" {
+				name = message.User.Name
+			}
+			if greeting := greetings.Greeting(message.User.Name, name, detected.Lang == "ja"); greeting != "" {
+				client.Say(joinChannelName, greeting)
+			}
+		}
+
+		// "8888" のように文字のない発言は翻訳しない
+		if detected.Lang == "" && strings.IndexFunc(cleanMsg, unicode.IsLetter) < 0 {
+			return
+		}
+
+		// 翻訳の希望（!notranslate / !translate）と最低文字数のチェック
+		// "www" のように言語がはっきりしない発言では auto モードを切り替えない
+		if detected.Confidence >= langMinConfidence {
+			trMode.Observe(detected.Lang, time.Now())
 		}
-		// 空白のメッセージはスキップ
-		if strings.TrimSpace(cleanMsg) == "" {
+		pref := chatters.TranslatePref(message.User.ID)
+		if pref == translateOff {
+			return
+		}
+		if pref != translateAlways && utf8.RuneCountInString(cleanMsg) < translateMinChars {
 			return
 		}
 
 		// ステップ2: MY_URLをバックグラウンドで呼び出し（外部トリガー用）
 		go http.Get(myURL)
 
-		// ステップ3: 言語判定と翻訳言語の決定
-		// 日本語を含む場合は英語に、それ以外は日本語に翻訳
-		targetLang := "JA"
-		if reJapanese.MatchString(cleanMsg) {
-			targetLang = "EN"
+		// ステップ3: 翻訳言語の決定（CHANNEL_LANGS があれば元言語以外の全言語、なければ TRANSLATE_ROUTES に従う）
+		targetLangs := routes.Targets(detected.Lang)
+		if len(channelLangs) > 0 {
+			targetLangs = langdetect.Routes{"*": channelLangs}.Targets(detected.Lang)
 		}
-
-		// ステップ4: DeepL APIで翻訳実行
-		translatedMsg, err := translateText(deepLApiKey, cleanMsg, targetLang)
-		if err != nil || translatedMsg == "" {
+		// 翻訳モード（!tr）で絞り込む
+		targetLangs = trMode.Filter(targetLangs, pref, time.Now())
+		if len(targetLangs) == 0 {
 			return
 		}
 
-		// ステップ5: ユーザー表示名を取得（ない場合はユーザーIDを使用）
+		// ステップ4: ユーザー表示名を取得（ない場合はユーザーIDを使用）
 		postUser := message.User.DisplayName
 		if postUser == "" {
 			postUser = message.User.Name
 		}
 
-		// ステップ6: 初投稿ユーザーに [新] タグを付与
-		first := ""
-		charUsrs[message.User.Name]++
-		if charUsrs[message.User.Name] == 1 {
-			first = "[新]"
+		// ステップ5: 翻訳言語ごとに翻訳してテンプレートで整形
+		var translated []string
+		for _, targetLang := range targetLangs {
+			translatedMsg, sourceLang, err := translateText(translator, maskedMsg, tokens, targetLang)
+			if err != nil {
+				log.Printf("translate %s failed: %v", targetLang, err)
+				// DeepL の残量切れは1度だけチャットで知らせる（使用文字数が変わったら再び知らせる）
+				if errors.Is(err, deepl.ErrQuotaExceeded) && quotaNoticeSent.CompareAndSwap(false, true) {
+					client.Say(joinChannelName, "⚠ DeepLの今月の翻訳上限に達したため、翻訳を停止しています (translation paused: DeepL quota exceeded)")
+				}
+				continue
+			}
+			if translatedMsg == "" {
+				continue
+			}
+			view := translationView{
+				Original:   message.Message,
+				Translated: translatedMsg,
+				Source:     sourceLang,
+				Target:     targetLang,
+				User:       postUser,
+				New:        firstEver,
+				Tag:        first,
+			}
+			if role := command.RoleOf(message.User); role > command.RoleEveryone {
+				view.Badge = role.String()
+			}
+			// まとめて投稿する場合、目印は先頭の1つだけに付ける
+			if combinedOutput && len(translated) > 0 {
+				view.New, view.Tag = false, ""
+			}
+			translated = append(translated, outputTemplates.Render(view))
 		}
 
-		// ステップ7: 翻訳済みメッセージをチャットに投稿
-		client.Say(joinChannelName, fmt.Sprintf("%s%s 【by %s】", first, translatedMsg, postUser))
-	})
-
-	// --- 4. 接続時：配信チェック ＆ Bluesky告知 ---
-	// Twitch チャットへの接続が確立されたときに実行されます。
-	// 配信情報を取得し、配信中であれば Bluesky に告知投稿します。
-	client.OnConnect(func() {
-		log.Printf("Connected to %s", joinChannelName)
-
-		// DeepL残量取得
-		count, limit, err := getUsage(deepLApiKey)
-		if err == nil {
-			UsedMsg = fmt.Sprintf("%d/%d", count, limit)
+		// ステップ6: 翻訳済みメッセージをチャットに投稿（500文字制限内に収める）
+		if len(translated) == 0 {
+			return
+		}
+		// reply モードでは元メッセージへのリプライとして投稿
+		post := func(text string) { client.Say(joinChannelName, text) }
+		if replyMode && message.ID != "" {
+			post = func(text string) { client.Reply(joinChannelName, message.ID, text) }
 		}
+		if combinedOutput {
+			// まとめて1メッセージ（長すぎる場合だけ分割）
+			for _, msg := range packMessages(translated, " / ", "", chatMessageLimit) {
+				post(msg)
+			}
+			return
+		}
+		for _, t := range translated {
+			post(truncateRunes(t, chatMessageLimit))
+		}
+	})
 
-		// Twitch配信情報取得
-		info, err := getStreamInfo(joinChannelName, clientID, clientSecret)
+	// --- 4. 配信の開始・終了 ---
+	// 配信していない間は翻訳せずに待機し、配信が始まったらチャットと Bluesky で告知します。
+	streams.On(func(ev streamEvent, st streamStatus) {
+		switch ev {
+		case StreamOnline:
+			chatters.ResetSession()
+			if usage != nil {
+				if err := usage.Refresh(); err != nil {
+					log.Printf("DeepL usage fetch failed: %v", err)
+				}
+				usage.MarkStreamStart()
+			}
+			client.Say(joinChannelName, fmt.Sprintf("🔴 配信開始を検知しました。翻訳を開始します (stream online: %s)", st.GameName))
 
-		if err == nil && len(info.Data) > 0 {
-			// 配信中の場合：Blueskyにリッチ告知
-			stream := info.Data[0]
+			// Blueskyにリッチ告知
 			streamURL := "https://twitch.tv/" + joinChannelName
 			bskyMsg := fmt.Sprintf("🔴 配信開始！\n【%s】\nカテゴリ: %s\n\n%s",
-				stream.Title, stream.GameName, streamURL)
-
+				st.Title, st.GameName, streamURL)
 			if bskyErr := postToBluesky(bskyMsg); bskyErr != nil {
 				log.Printf("Bluesky post skipped/failed: %v", bskyErr)
 			}
-		} else if err == nil && len(info.Data) == 0 {
-			// ID設定はあるが、配信してない場合は終了
-			log.Println("配信中ではないため、Botを終了します。")
-			os.Exit(0)
-			return
-		} else {
-			// ID設定自体がない場合は、配信チェックを無視して翻訳Botとして継続
-			log.Printf("Twitch API設定がないため、配信チェックをスキップして継続します。")
+		case StreamOffline:
+			// 配信終了時の後片付け（履歴・キャッシュを保存して、次の配信まで待機）
+			if err := chatters.Save(); err != nil {
+				log.Printf("chatter store save failed: %v", err)
+			}
+			if err := translateCache.Save(); err != nil {
+				log.Printf("translate cache save failed: %v", err)
+			}
+			msg := "📴 配信終了を検知しました。おつかれさまでした！ (stream offline)"
+			if summary := usage.StreamSummary(); summary != "" {
+				msg += " | DeepL " + summary
+			}
+			client.Say(joinChannelName, msg)
 		}
+	})
+
+	// EventSub（EVENTSUB_BROADCASTER_ID を設定すると有効）
+	// フォロー・レイド・サブスク・ビッツにお礼を投稿し、配信の開始・終了もすぐに検知します。
+	// トークンは EVENTSUB_TOKEN（未設定なら /auth/login で認証したもの、または OAUTH_TOKEN）で、次のスコープが必要です:
+	// moderator:read:followers, channel:read:subscriptions, bits:read
+	// EVENTSUB_MODERATOR_ID にはトークンの持ち主のユーザーID（未設定なら配信者本人のトークンとみなす）
+	var eventSub *eventsub.Client
+	if broadcasterID := os.Getenv("EVENTSUB_BROADCASTER_ID"); broadcasterID != "" && clientID != "" {
+		eventSubToken := os.Getenv("EVENTSUB_TOKEN")
+		if eventSubToken == "" {
+			eventSubToken = oauthToken
+		}
+		tokenFunc := func() (string, error) { return eventSubToken, nil }
+		if os.Getenv("EVENTSUB_TOKEN") == "" && chatLogin != nil {
+			// /auth/login で認証したトークンを使う（自動で更新される）
+			tokenFunc = chatLogin.auth.Token
+		}
+		eventSub = eventsub.NewClient(clientID, tokenFunc,
+			eventsub.ChannelSubscriptions(broadcasterID, os.Getenv("EVENTSUB_MODERATOR_ID")))
+		// EVENTSUB_WS_URL / EVENTSUB_API_URL で Twitch CLI のモックなどに接続先を変更できる
+		if u := os.Getenv("EVENTSUB_WS_URL"); u != "" {
+			eventSub.WebSocketURL = u
+		}
+		if u := os.Getenv("EVENTSUB_API_URL"); u != "" {
+			eventSub.HelixURL = u
+		}
+		registerEventHandlers(eventSub, &eventNotifier{
+			messages: botMessages.data.EventMessages,
+			say:      func(text string) { client.Say(joinChannelName, text) },
+		}, streams)
+	}
+
+	// --- 5. 接続時：起動メッセージ ＆ 配信状態の監視開始 ---
+	// Twitch チャットへの接続が確立されたときに実行されます。
+	var watchOnce sync.Once
+	client.OnConnect(func() {
+		log.Printf("Connected to %s", joinChannelName)
+
+		// DeepL残量取得（DeepL を使わない設定ならスキップ）
+		if usage != nil {
+			if err := usage.Refresh(); err != nil {
+				log.Printf("DeepL usage fetch failed: %v", err)
+			}
+		}
+
+		// 配信状態の監視と EventSub を開始（再接続しても1度だけ）
+		watchOnce.Do(func() {
+			if streams != nil {
+				go streams.run()
+			} else {
+				// Twitch API の設定がない場合は、配信チェックを無視して翻訳Botとして継続
+				log.Printf("Twitch API設定がないため、配信チェックをスキップして継続します。")
+			}
+			if eventSub != nil {
+				go eventSub.Run(context.Background())
+			}
+		})
 
 		// Twitchチャットへの起動メッセージ
 		// バージョン情報を組み込んだ起動メッセージ
-		startMsg := "⚙ bot起動 | " + formatStatus(BotVersion, UsedMsg, calculateRemainingWeeks())
+		startMsg := "⚙ bot起動 | " + formatStatus(BotVersion, usage.String(), cacheStats(translateCache), calculateRemainingWeeks())
 
 		client.Say(joinChannelName, startMsg)
 	})
 
-	// --- 5. SYNDUALITY 15分前通知タイマー ---
+	// --- 6. SYNDUALITY 15分前通知タイマー ---
 	// 1分ごとに「15分後」の予定をチェックする
 	go func() {
 		// 次の「00秒」まで待機して同期（リテラシーへのこだわり）
@@ -221,7 +620,15 @@ func main() {
 	}()
 
 	client.Join(joinChannelName)
-	if err := client.Connect(); err != nil {
+	if chatLogin != nil {
+		go chatLogin.watch(client)
+		// まだ認証していなければ、/auth/login で認証されるまで待つ
+		if oauthToken == "" {
+			log.Printf("⏳ チャット用のトークンがありません。%s で認証してください。", chatLogin.loginURL)
+			<-chatLogin.reauthorized
+		}
+	}
+	if err := connectChat(client, chatLogin); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -231,37 +638,16 @@ func main() {
 // getStreamInfo は Twitch API を使用して指定チャンネルの配信情報を取得します。
 // 入力：
 //   - channelName: Twitchチャンネル名
-//   - clientID: Twitch OAuth Client ID
-//   - clientSecret: Twitch OAuth Client Secret
+//   - hc: Helix クライアント（CLIENT_ID / CLIENT_SECRET 未設定なら nil）
 //
 // 出力：
-//   - TwitchStreamInfo 構造体へのポインタ（タイトルとゲーム名を含む）
+//   - 配信情報（タイトルとゲーム名を含む。配信していなければ nil）
 //   - エラー（認証情報がない場合など）
-func getStreamInfo(channelName, clientID, clientSecret string) (*TwitchStreamInfo, error) {
-	if clientID == "" || clientSecret == "" {
+func getStreamInfo(channelName string, hc *helix.Client) (*helix.Stream, error) {
+	if hc == nil {
 		return nil, fmt.Errorf("credentials not set")
 	}
-	r := resty.New()
-	tResp, err := r.R().SetQueryParams(map[string]string{
-		"client_id": clientID, "client_secret": clientSecret, "grant_type": "client_credentials",
-	}).Post("https://id.twitch.tv/oauth2/token")
-	if err != nil {
-		return nil, err
-	}
-	var tData map[string]interface{}
-	json.Unmarshal(tResp.Body(), &tData)
-	token, ok := tData["access_token"].(string)
-	if !ok {
-		return nil, fmt.Errorf("token error")
-	}
-	resp, err := r.R().SetHeader("Client-ID", clientID).SetHeader("Authorization", "Bearer "+token).
-		SetQueryParam("user_login", channelName).Get("https://api.twitch.tv/helix/streams")
-	if err != nil {
-		return nil, err
-	}
-	var info TwitchStreamInfo
-	json.Unmarshal(resp.Body(), &info)
-	return &info, nil
+	return hc.Stream(channelName)
 }
 
 // postToBluesky は指定されたテキストを Bluesky ATProtocol API 経由で投稿します。
@@ -320,55 +706,45 @@ func postToBluesky(text string) error {
 	return err
 }
 
-// translateText は DeepL API を使用してテキストを翻訳します。
-// デフォルトで "api-free.deepl.com" のフリープランエンドポイントを使用します。
+// translateText は設定された翻訳バックエンドを使用してテキストを翻訳します。
 // 入力：
-//   - apiKey: DeepL API キー
-//   - text: 翻訳対象テキスト
-//   - targetLang: 翻訳言語コード（"JA" または "EN"）
+//   - t: 翻訳バックエンド（DeepL / LibreTranslate / 辞書）
+//   - text: 翻訳対象テキスト（tokenize.Protect で目印に置き換え済み）
+//   - tokens: 目印を元に戻すための語（エモート・@メンション・URL など）
+//   - targetLang: 翻訳言語コード（"JA" / "EN" / "KO" など DeepL 形式）
 //
 // 出力：
-//   - 翻訳済みテキスト（言語コード付き形式: "翻訳文 (元言語 > 目標言語)）
+//   - 翻訳済みテキスト（目印は元の語に復元済み）
+//   - 翻訳元の言語コード（辞書など不明な場合は空文字列）
 //   - エラー（API呼び出し失敗など）
 //
 // 注意：翻訳元言語が既に目標言語と同じ場合は空文字列を返します。
-func translateText(apiKey, text, targetLang string) (string, error) {
-	resp, err := resty.New().R().SetHeader("Authorization", "DeepL-Auth-Key "+apiKey).
-		SetQueryParams(map[string]string{"text": text, "target_lang": targetLang}).
-		Post("https://api-free.deepl.com/v2/translate")
-	if err != nil {
-		return "", err
+func translateText(t translate.Translator, text string, tokens []string, targetLang string) (string, string, error) {
+	res, err := t.Translate(text, targetLang)
+	if err != nil || res == nil {
+		return "", "", err
 	}
-	var result map[string]interface{}
-	json.Unmarshal(resp.Body(), &result)
-	if trans, ok := result["translations"].([]interface{}); ok && len(trans) > 0 {
-		t := trans[0].(map[string]interface{})
-		// 言語が既に一致している場合は翻訳不要（空文字列を返す）
-		if t["detected_source_language"].(string) == targetLang {
-			return "", nil
-		}
-		// 翻訳済みテキストを「翻訳文 (元言語 > 目標言語)」形式で返す
-		return fmt.Sprintf("%s (%s > %s)", t["text"].(string), t["detected_source_language"].(string), targetLang), nil
+	// 言語が既に一致している場合は翻訳不要（空文字列を返す）
+	if langdetect.SameLang(res.SourceLang, targetLang) {
+		return "", "", nil
 	}
-	return "", nil
+	return tokenize.Restore(res.Text, tokens), res.SourceLang, nil
 }
 
 // getUsage は DeepL API の現在の使用状況を取得します。
 // 入力：
-//   - apiKey: DeepL API キー
+//   - c: DeepL クライアント
 //
 // 出力：
 //   - character_count: 今月のキャラクター使用数
-//   - character_limit: 月当たりの使用可能なキャラクター数（フリープラン限定）
+//   - character_limit: 月当たりの使用可能なキャラクター数
 //   - エラー（API呼び出し失敗など）
-func getUsage(apiKey string) (int, int, error) {
-	resp, err := resty.New().R().SetHeader("Authorization", "DeepL-Auth-Key "+apiKey).Get("https://api-free.deepl.com/v2/usage")
+func getUsage(c *deepl.Client) (int, int, error) {
+	u, err := c.Usage()
 	if err != nil {
 		return 0, 0, err
 	}
-	var r map[string]interface{}
-	json.Unmarshal(resp.Body(), &r)
-	return int(r["character_count"].(float64)), int(r["character_limit"].(float64)), nil
+	return u.CharacterCount, u.CharacterLimit, nil
 }
 
 // calculateRemainingWeeks は 今日から年末（12月31日）までの残り週数を計算します。
@@ -380,11 +756,18 @@ func calculateRemainingWeeks() int {
 	return (int(endOfYear.Sub(now).Hours()/24) + 6) / 7
 }
 
-func formatStatus(version, deepl string, weeks int) string {
+func formatStatus(version, deepl, cache string, weeks int) string {
 	return fmt.Sprintf(
-		"OK 🟢 | ver:%s | DeepL:%s | %d週",
+		"OK 🟢 | ver:%s | DeepL:%s (cache %s) | %d週",
 		version,
 		deepl,
+		cache,
 		weeks,
 	)
 }
+
+// cacheStats は翻訳キャッシュのヒット/ミス数を "hit 12/miss 30" 形式で返します
+func cacheStats(c *translate.Cache) string {
+	hits, misses := c.Stats()
+	return fmt.Sprintf("hit %d/miss %d", hits, misses)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// chatMessageLimit は Twitch チャット1メッセージの最大文字数です
+const chatMessageLimit = 500
+
+// packMessages は parts を sep でつないで、末尾に suffix を付けたメッセージにまとめます。
+// limit 文字を超える場合は複数のメッセージに分け、1つで収まらない part は切り詰めます。
+func packMessages(parts []string, sep, suffix string, limit int) []string {
+	room := limit - utf8.RuneCountInString(suffix)
+	var msgs []string
+	cur := ""
+	for _, p := range parts {
+		p = truncateRunes(p, room)
+		switch {
+		case cur == "":
+			cur = p
+		case utf8.RuneCountInString(cur+sep+p) <= room:
+			cur += sep + p
+		default:
+			msgs = append(msgs, cur+suffix)
+			cur = p
+		}
+	}
+	if cur != "" {
+		msgs = append(msgs, cur+suffix)
+	}
+	return msgs
+}
+
+// truncateRunes は s を max 文字以内に切り詰めます（切った場合は末尾を "…" にする）
+func truncateRunes(s string, max int) string {
+	if max <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:max-1]) + "…"
+}
+
+// 翻訳メッセージの既定テンプレート（botMessage.json の TranslationTemplates で言語ごとに上書き可）
+const (
+	defaultByTemplate    = `{{.Tag}}{{.Translated}} ({{with .Source}}{{.}} {{end}}> {{.Target}}) 【by {{.User}}】`
+	defaultReplyTemplate = `{{.Tag}}{{.Translated}} ({{with .Source}}{{.}} {{end}}> {{.Target}})`
+)
+
+// translationView は翻訳メッセージのテンプレートに渡す値です
+type translationView struct {
+	Original   string // 元のメッセージ
+	Translated string // 翻訳文
+	Source     string // 翻訳元の言語（"EN" など、不明なら空）
+	Target     string // 翻訳先の言語
+	User       string // 発言者の表示名
+	New        bool   // このチャンネルで初めての発言か
+	Tag        string // "[新]" などの目印
+	Badge      string // 発言者の権限（"moderator" / "vip" / "subscriber" など、一般は空）
+}
+
+// translationTemplates は翻訳先の言語ごとの出力テンプレートです
+type translationTemplates struct {
+	byLang map[string]*template.Template // 言語コード（大文字）→ テンプレート、"*" が既定
+}
+
+// newTranslationTemplates は設定からテンプレートを作ります。"*" の指定がなければ投稿方法に応じた既定を使います。
+// キーに "reply:JA" / "by:JA" のように投稿方法を付けると、その投稿方法のときだけ使い、"JA" より優先します
+func newTranslationTemplates(conf map[string]string, replyMode bool) (*translationTemplates, error) {
+	t := &translationTemplates{byLang: map[string]*template.Template{}}
+	src := map[string]string{"*": defaultByTemplate}
+	mode := "by"
+	if replyMode {
+		src["*"] = defaultReplyTemplate
+		mode = "reply"
+	}
+	modeSpecific := map[string]string{}
+	for key, text := range conf {
+		prefix, lang, ok := strings.Cut(key, ":")
+		if !ok {
+			src[strings.ToUpper(key)] = text
+			continue
+		}
+		switch strings.ToLower(prefix) {
+		case mode:
+			modeSpecific[strings.ToUpper(lang)] = text
+		case "reply", "by":
+			// 別の投稿方法用
+		default:
+			return nil, fmt.Errorf("translation template %s: unknown mode %q", key, prefix)
+		}
+	}
+	for lang, text := range modeSpecific {
+		src[lang] = text
+	}
+	for lang, text := range src {
+		tmpl, err := template.New(lang).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("translation template %s: %v", lang, err)
+		}
+		t.byLang[lang] = tmpl
+	}
+	return t, nil
+}
+
+// Render は翻訳先の言語に合うテンプレート（"EN-US" → "EN" → "*" の順に探す）で整形します
+func (t *translationTemplates) Render(v translationView) string {
+	lang := strings.ToUpper(v.Target)
+	tmpl, ok := t.byLang[lang]
+	if !ok {
+		base, _, _ := strings.Cut(lang, "-")
+		if tmpl, ok = t.byLang[base]; !ok {
+			tmpl = t.byLang["*"]
+		}
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, v); err != nil {
+		log.Printf("translation template %s: %v", tmpl.Name(), err)
+		return v.Translated
+	}
+	return b.String()
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/k-p5w/go-marybot/internal/helix"
+)
+
+// streamEvent は配信状態の変化の種類です
+type streamEvent int
+
+const (
+	StreamOnline  streamEvent = iota + 1 // 配信開始
+	StreamOffline                        // 配信終了
+)
+
+func (e streamEvent) String() string {
+	switch e {
+	case StreamOnline:
+		return "online"
+	case StreamOffline:
+		return "offline"
+	}
+	return "unknown"
+}
+
+// streamStatus は配信中のタイトル・カテゴリなどです（オフラインならゼロ値）
+type streamStatus struct {
+	Title     string
+	GameName  string
+	StartedAt time.Time
+}
+
+// streamWatcher は Helix /streams を定期的に確認し、配信の開始・終了を通知します。
+// 配信していない間も bot は終了せず待機し、配信が始まったら StreamOnline を発行します。
+type streamWatcher struct {
+	fetch    func() (*helix.Stream, error) // 配信していなければ nil を返す
+	interval time.Duration
+	grace    int // 何回続けて「配信なし」なら終了とみなすか（API の一時的な取りこぼし対策）
+
+	mu       sync.Mutex
+	known    bool // 1度でも状態を確認できたか
+	live     bool
+	stream   streamStatus
+	misses   int
+	handlers []func(ev streamEvent, st streamStatus)
+}
+
+// newStreamWatcher は interval ごとに fetch で配信状態を確認する watcher を作ります
+func newStreamWatcher(fetch func() (*helix.Stream, error), interval time.Duration) *streamWatcher {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &streamWatcher{fetch: fetch, interval: interval, grace: 2}
+}
+
+// On は配信開始・終了時に呼ばれる処理を登録します（watcher がなければ何もしない）
+func (w *streamWatcher) On(h func(ev streamEvent, st streamStatus)) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.handlers = append(w.handlers, h)
+	w.mu.Unlock()
+}
+
+// Live は配信中かどうかを返します。watcher がない（Twitch API 未設定）場合や、
+// まだ1度も配信状態を確認できていない（API のエラーが続いている）場合は配信中として扱い、翻訳を止めません
+func (w *streamWatcher) Live() bool {
+	if w == nil {
+		return true
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return !w.known || w.live
+}
+
+// Poll は配信状態を1回確認し、変化があればイベントを発行します
+func (w *streamWatcher) Poll() error {
+	s, err := w.fetch()
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		w.set(false, streamStatus{})
+		return nil
+	}
+	w.set(true, streamStatus{Title: s.Title, GameName: s.GameName, StartedAt: s.StartedAt})
+	return nil
+}
+
+// set は確認した配信状態を反映します（EventSub など別経路の通知からも呼べる）
+func (w *streamWatcher) set(live bool, st streamStatus) {
+	w.mu.Lock()
+	var ev streamEvent
+	switch {
+	case live:
+		w.misses = 0
+		// 配信中に再起動した場合も1度だけ開始として扱う
+		if !w.live || (!st.StartedAt.IsZero() && !st.StartedAt.Equal(w.stream.StartedAt)) {
+			ev = StreamOnline
+		}
+		w.live, w.stream = true, st
+	case w.live:
+		w.misses++
+		if w.misses >= w.grace {
+			ev = StreamOffline
+			st = w.stream
+			w.live, w.stream, w.misses = false, streamStatus{}, 0
+		}
+	case !w.known:
+		log.Println("配信中ではないため、配信開始まで待機します。")
+	}
+	w.known = true
+	handlers := w.handlers
+	w.mu.Unlock()
+
+	if ev == 0 {
+		return
+	}
+	log.Printf("stream %s: %s / %s", ev, st.Title, st.GameName)
+	for _, h := range handlers {
+		h(ev, st)
+	}
+}
+
+// Notify は EventSub などから配信開始・終了の通知を受けたときに呼びます。
+// 開始はタイトルなどを取り直すため Poll し、終了は猶予なしですぐに反映します
+func (w *streamWatcher) Notify(ev streamEvent) {
+	if w == nil {
+		return
+	}
+	if ev == StreamOffline {
+		w.mu.Lock()
+		w.misses = w.grace - 1
+		w.mu.Unlock()
+		w.set(false, streamStatus{})
+		return
+	}
+	if err := w.Poll(); err != nil {
+		log.Printf("stream watch failed: %v", err)
+	}
+}
+
+// run は interval ごとに Poll を呼び出します（goroutine で起動）
+func (w *streamWatcher) run() {
+	if err := w.Poll(); err != nil {
+		log.Printf("❌ 配信状態を取得できません。確認できるまで配信中として翻訳を続けます（CLIENT_ID / CLIENT_SECRET を確認してください）: %v", err)
+	}
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := w.Poll(); err != nil {
+			log.Printf("stream watch failed: %v", err)
+		}
+	}
+}
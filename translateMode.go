@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/k-p5w/go-marybot/internal/command"
+	"github.com/k-p5w/go-marybot/internal/langdetect"
+)
+
+const defaultStateFile = "botState.json"
+
+// 翻訳モード（!tr で切り替え）
+const (
+	modeOn     = "on"      // 通常どおり翻訳
+	modeOff    = "off"     // 翻訳しない
+	modeAuto   = "auto"    // 直近に日本語以外の発言があったときだけ翻訳
+	modeJAOnly = "ja-only" // 日本語への翻訳だけ行う
+	modeENOnly = "en-only" // 英語への翻訳だけ行う
+)
+
+// botState は再起動しても残したい bot の状態です（botState.json）
+type botState struct {
+	TranslateMode string `json:"translateMode"`
+}
+
+// translateModeState はチャンネル全体の翻訳モードを管理します
+type translateModeState struct {
+	mu          sync.Mutex
+	path        string
+	state       botState
+	autoWindow  time.Duration // auto モードで翻訳を続ける時間
+	lastForeign time.Time     // 最後に日本語以外の発言があった時刻
+}
+
+// loadTranslateMode は保存された翻訳モードを読み込みます（なければ on）
+func loadTranslateMode(path string, autoWindow time.Duration) (*translateModeState, error) {
+	m := &translateModeState{path: path, state: botState{TranslateMode: modeOn}, autoWindow: autoWindow}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &m.state); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if !validMode(m.state.TranslateMode) {
+		m.state.TranslateMode = modeOn
+	}
+	return m, nil
+}
+
+func validMode(mode string) bool {
+	switch mode {
+	case modeOn, modeOff, modeAuto, modeJAOnly, modeENOnly:
+		return true
+	}
+	return false
+}
+
+// Mode は現在の翻訳モードを返します
+func (m *translateModeState) Mode() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state.TranslateMode
+}
+
+// Set は翻訳モードを変更してファイルへ保存します
+func (m *translateModeState) Set(mode string) error {
+	if !validMode(mode) {
+		return fmt.Errorf("unknown mode %q", mode)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state.TranslateMode = mode
+	b, err := json.MarshalIndent(m.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.path, b, 0644)
+}
+
+// Observe は発言の言語を記録します（auto モードの判定用）
+func (m *translateModeState) Observe(lang string, now time.Time) {
+	if lang == "" || lang == "ja" {
+		return
+	}
+	m.mu.Lock()
+	m.lastForeign = now
+	m.mu.Unlock()
+}
+
+// Filter は現在のモードに合わせて翻訳先を絞り込みます。
+// always に設定したユーザーは auto モードでも翻訳します（off モードは除く）
+func (m *translateModeState) Filter(targets []string, pref string, now time.Time) []string {
+	m.mu.Lock()
+	mode, last := m.state.TranslateMode, m.lastForeign
+	m.mu.Unlock()
+
+	switch mode {
+	case modeOff:
+		return nil
+	case modeAuto:
+		if pref != translateAlways && now.Sub(last) > m.autoWindow {
+			return nil
+		}
+	case modeJAOnly, modeENOnly:
+		want := strings.ToUpper(strings.TrimSuffix(mode, "-only"))
+		var out []string
+		for _, t := range targets {
+			if langdetect.SameLang(want, t) {
+				out = append(out, t)
+			}
+		}
+		return out
+	}
+	return targets
+}
+
+// registerTranslateModeCommand は !tr を登録します（モデレーター以上）
+func registerTranslateModeCommand(reg *command.Registry, m *translateModeState) {
+	reg.Register(&command.Command{
+		Name:        "tr",
+		Description: "翻訳モード切替",
+		Usage:       "!tr on|off|auto|ja-only|en-only",
+		MinRole:     command.RoleModerator,
+		Handler: func(c *command.Context) string {
+			if len(c.Args) == 0 {
+				return "🌐 翻訳モード: " + m.Mode() + " | 使い方: !tr on|off|auto|ja-only|en-only"
+			}
+			if err := m.Set(strings.ToLower(c.Args[0])); err != nil {
+				return "❌ " + err.Error() + " | 使い方: !tr on|off|auto|ja-only|en-only"
+			}
+			return "🌐 翻訳モードを " + m.Mode() + " にしました"
+		},
+	})
+}
@@ -5,17 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"math" // 追加
-	"net/http"
 	"os"
-	"strings"
 	"time"
-)
 
-const (
-	authURL    = "https://id.twitch.tv/oauth2/token"
-	baseURL    = "https://api.twitch.tv/helix/"
-	gameURL    = "https://api.twitch.tv/helix/search/categories"
-	topGameURL = "https://api.twitch.tv/helix/games/top"
+	"github.com/k-p5w/go-marybot/internal/helix"
+	"github.com/k-p5w/go-marybot/internal/twitchauth"
 )
 
 // ゲーム名マップを読み込む関数
@@ -34,60 +28,25 @@ func loadGameNameMap(filePath string) (map[string]map[string]string, error) {
 	return gameNameMap, nil
 }
 
-// アクセストークン取得
-func getAccessToken(clientID, clientSecret string) (string, error) {
-	data := fmt.Sprintf("client_id=%s&client_secret=%s&grant_type=client_credentials", clientID, clientSecret)
-	resp, err := http.Post(authURL, "application/x-www-form-urlencoded", strings.NewReader(data))
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
-
-	if token, ok := result["access_token"].(string); ok {
-		return token, nil
-	}
-	return "", fmt.Errorf("アクセストークンの取得に失敗しました: %v", result)
-}
-
 // ストリーマー情報を整形して表示する関数
-func formatAndDisplayStreamers(data map[string]interface{}) {
-	cnt := 0
-	if streams, ok := data["data"].([]interface{}); ok {
-		for _, stream := range streams {
-			cnt += 1
-			if streamInfo, ok := stream.(map[string]interface{}); ok {
-				user_login := streamInfo["user_login"].(string)
-				userName := streamInfo["user_name"].(string)
-				viewerCount := streamInfo["viewer_count"].(float64)
-				title := streamInfo["title"].(string)
-				gameId := streamInfo["game_id"].(string)
-				gameTitle := streamInfo["game_name"].(string)
-				language := streamInfo["language"].(string)
-				fmt.Printf("No%v.%v[%v:%v]a.配信者: %v/%s\nb.視聴者: %.0f\nc.タイトル: %s\n---\n", cnt, language, gameId, gameTitle, user_login, userName, viewerCount, title)
-			}
-		}
-	} else {
-		fmt.Println("No stream data available or unexpected format.")
+func formatAndDisplayStreamers(streams []helix.Stream) {
+	if len(streams) == 0 {
+		fmt.Println("No stream data available.")
+		return
+	}
+	for i, stream := range streams {
+		fmt.Printf("No%v.%v[%v:%v]a.配信者: %v/%s\nb.視聴者: %d\nc.タイトル: %s\n---\n",
+			i+1, stream.Language, stream.GameID, stream.GameName, stream.UserLogin, stream.UserName, stream.ViewerCount, stream.Title)
 	}
 }
 
-// カテゴリごとの配信者数を集計して表示する関数
-func countStreamersByCategory(data map[string]interface{}, targetCategory string) {
+// カテゴリごとの配信者数を集計して表示する関数（targetCategory はカテゴリIDまたはカテゴリ名）
+func countStreamersByCategory(streams []helix.Stream, targetCategory string) {
 	categoryCounts := make(map[string]bool) // ユニークな配信者を記録
 
-	if streams, ok := data["data"].([]interface{}); ok {
-		for _, stream := range streams {
-			if streamInfo, ok := stream.(map[string]interface{}); ok {
-				category, categoryOk := streamInfo["game_name"].(string)
-				userName, userNameOk := streamInfo["user_name"].(string)
-
-				if categoryOk && userNameOk && category == targetCategory {
-					categoryCounts[userName] = true
-				}
-			}
+	for _, stream := range streams {
+		if stream.GameID == targetCategory || stream.GameName == targetCategory {
+			categoryCounts[stream.UserID] = true
 		}
 	}
 
@@ -95,31 +54,18 @@ func countStreamersByCategory(data map[string]interface{}, targetCategory string
 	fmt.Printf("%s: %d ユニーク配信者数\n", targetCategory, len(categoryCounts))
 }
 
-// ストリーマー情報取得
-func getStreamers(clientID, category string, token string) error {
-
-	url := fmt.Sprintf("%sstreams?first=100&game_id=%s", baseURL, category)
-	fmt.Println("Request URL:", url) // デバッグ用にURLを出力
-
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("Client-ID", clientID)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// ストリーマー情報取得（ページ送りをたどって全件取得）
+func getStreamers(hc *helix.Client, category string) error {
+	streams, err := hc.Streams(helix.StreamsQuery{GameIDs: []string{category}})
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
 
 	// 整形して表示
-	formatAndDisplayStreamers(result)
+	formatAndDisplayStreamers(streams)
 
 	// カテゴリごとの配信者数を集計して表示
-	countStreamersByCategory(result, category)
+	countStreamersByCategory(streams, category)
 
 	return nil
 }
@@ -162,7 +108,7 @@ var excludedCategoryIDs = map[string]bool{
 
 // --------------------------------------------------------
 
-func getTotalViewersForTopGames(clientID, token string, gameNameFile string) error {
+func getTotalViewersForTopGames(hc *helix.Client, gameNameFile string) error {
 	// 出力用ディレクトリを作成（なければ作成）
 	outputDir := "output"
 	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
@@ -177,25 +123,10 @@ func getTotalViewersForTopGames(clientID, token string, gameNameFile string) err
 		return fmt.Errorf("failed to load game name map: %v", err)
 	}
 
-	topGamesURL := "https://api.twitch.tv/helix/games/top?first=100"
-	req, _ := http.NewRequest("GET", topGamesURL, nil)
-	req.Header.Set("Client-ID", clientID)
-	req.Header.Set("Authorization", "Bearer "+token)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	topGames, err := hc.TopGames(100)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	var result struct {
-		Data []struct {
-			ID   string `json:"id"`
-			Name string `json:"name"`
-		} `json:"data"`
-	}
-	json.NewDecoder(resp.Body).Decode(&result)
 
 	// ヘッダー定義 (配慮された用語を使用)
 	header := []string{
@@ -221,25 +152,12 @@ func getTotalViewersForTopGames(clientID, token string, gameNameFile string) err
 	totalViewersTop10 := 0
 
 	// 各ゲームごとに集計
-	for i, game := range result.Data {
-		// 各ゲームの配信情報を取得
-		streamsURL := fmt.Sprintf("https://api.twitch.tv/helix/streams?first=100&game_id=%s", game.ID)
-		req, _ := http.NewRequest("GET", streamsURL, nil)
-		req.Header.Set("Client-ID", clientID)
-		req.Header.Set("Authorization", "Bearer "+token)
-
-		resp, err := client.Do(req)
+	for i, game := range topGames {
+		// 各ゲームの配信情報を取得（ページ送りをたどって全配信者を集計）
+		streams, err := hc.Streams(helix.StreamsQuery{GameIDs: []string{game.ID}})
 		if err != nil {
 			return err
 		}
-		defer resp.Body.Close()
-
-		var streamsResult struct {
-			Data []struct {
-				ViewerCount int `json:"viewer_count"`
-			} `json:"data"`
-		}
-		json.NewDecoder(resp.Body).Decode(&streamsResult)
 
 		streamerCnt := 0
 		gameViewersTop3 := 0
@@ -251,7 +169,7 @@ func getTotalViewersForTopGames(clientID, token string, gameNameFile string) err
 		var sumSquares float64 = 0.0
 
 		// 配信者ごとに視聴者数を集計
-		for j, stream := range streamsResult.Data {
+		for j, stream := range streams {
 			streamerCnt++
 			gameViewersALL += stream.ViewerCount
 
@@ -341,10 +259,7 @@ func getTotalViewersForTopGames(clientID, token string, gameNameFile string) err
 
 				txtFileName := fmt.Sprintf("%s/%s_%s_%s.txt", outputDir, game.ID, fileTime, rankStr)
 
-				streamerCntStr := fmt.Sprintf("%d名", streamerCnt)
-				if streamerCnt >= 100 {
-					streamerCntStr = "100名+"
-				}
+				streamerCntStr := formatWithSpace(streamerCnt) + "名"
 				txt := fmt.Sprintf(
 					"%s\n=-=総配信者数=-=\n%s\n\n=-=総視聴者数=-=\n%s人\n\n==TOP3の視聴者合計==\n%.1f%%（%s人）\n",
 					gameName,
@@ -402,34 +317,16 @@ func getTotalViewersForTopGames(clientID, token string, gameNameFile string) err
 }
 
 // 人気カテゴリを取得する関数
-func getTopGamesOrg(clientID, token string) error {
-	url := fmt.Sprintf("%s?first=%d", topGameURL, 100)
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("Client-ID", clientID)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+func getTopGamesOrg(hc *helix.Client) error {
+	games, err := hc.TopGames(100)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
 
 	// 人気カテゴリを表示
-	if data, ok := result["data"].([]interface{}); ok {
-		fmt.Println("人気カテゴリ:")
-		for _, item := range data {
-			if game, ok := item.(map[string]interface{}); ok {
-				id := game["id"].(string)
-				name := game["name"].(string)
-				fmt.Printf("Game ID: %s, Name: %s\n", id, name)
-			}
-		}
-	} else {
-		fmt.Println("No data found or unexpected format.")
+	fmt.Println("人気カテゴリ:")
+	for _, game := range games {
+		fmt.Printf("Game ID: %s, Name: %s\n", game.ID, game.Name)
 	}
 
 	return nil
@@ -438,61 +335,41 @@ func getTopGamesOrg(clientID, token string) error {
 // PopStreaming は、Twitchのストリーミング情報を取得して表示する関数です。
 func PopStreaming(item *Config) {
 
-	// アクセストークンを取得
-	token, err := getAccessToken(item.ClientID, item.ClientSecret)
-	if err != nil {
-		fmt.Println("エラー:", err)
-		return
-	}
+	// アクセストークンは各API呼び出しで共有（期限切れ前に自動で取り直す）
+	hc := helix.New(twitchauth.NewAppTokenSource(item.ClientID, item.ClientSecret))
 
 	// 人気カテゴリを取得
 	fmt.Println("人気カテゴリを取得中...")
-	err = getTotalViewersForTopGames(item.ClientID, token, "twitchGames.json")
+	err := getTotalViewersForTopGames(hc, "twitchGames.json")
 	if err != nil {
 		fmt.Println("人気カテゴリの取得に失敗しました:", err)
 	}
 
 	// 取得したいカテゴリを設定
 	find := "final-fantasy-xi-online"
-	getCategory(find, item.ClientID, token)
+	getCategory(find, hc)
 
 	category := "509658"
 	category = "10229"
-	err = getStreamers(item.ClientID, category, token)
+	err = getStreamers(hc, category)
 	if err != nil {
 		fmt.Println("エラー:", err)
 	}
 }
 
 // GET https://api.twitch.tv/helix/search/categories?query=Minecraft
-func getCategory(find, clientID, token string) error {
-
-	url := fmt.Sprintf("%s?query=%s", gameURL, find)
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("Client-ID", clientID)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-	client := &http.Client{}
-	resp, err := client.Do(req)
+func getCategory(find string, hc *helix.Client) error {
+	categories, err := hc.SearchCategories(find, 0)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	var result map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&result)
-
-	// IDを抽出
-	if data, ok := result["data"].([]interface{}); ok {
-		for _, item := range data {
-			if category, ok := item.(map[string]interface{}); ok {
-				if id, ok := category["id"].(string); ok {
-					name := category["name"].(string)
-					fmt.Printf("Category ID:%v[%v] \n", id, name)
-				}
-			}
-		}
-	} else {
-		fmt.Println("No data found or unexpected format.")
+	if len(categories) == 0 {
+		fmt.Println("No data found.")
+	}
+
+	// IDを表示
+	for _, category := range categories {
+		fmt.Printf("Category ID:%v[%v] \n", category.ID, category.Name)
 	}
 
 	return nil
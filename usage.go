@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// deepLUsage は DeepL の使用量を保持します。
+// バックグラウンドで定期的に更新され、!status や Web ページから同時に読まれるため mutex で守ります。
+type deepLUsage struct {
+	mu          sync.RWMutex
+	count       int
+	limit       int
+	known       bool
+	streamStart int  // 配信開始時点の使用文字数
+	streamSet   bool // streamStart を記録済みか
+
+	fetch    func() (count, limit int, err error)
+	onUpdate func(count, limit int, now time.Time) // 更新のたびに呼ばれる（残量管理へ渡す）
+}
+
+// Refresh は使用量を取得し直します。配信開始時点の値が未記録なら今の値を記録します
+func (u *deepLUsage) Refresh() error {
+	count, limit, err := u.fetch()
+	if err != nil {
+		return err
+	}
+	u.mu.Lock()
+	u.count, u.limit, u.known = count, limit, true
+	if !u.streamSet {
+		u.streamStart, u.streamSet = count, true
+	}
+	u.mu.Unlock()
+
+	if u.onUpdate != nil {
+		u.onUpdate(count, limit, time.Now())
+	}
+	return nil
+}
+
+// run は interval ごとに Refresh を呼び出します（goroutine で起動）
+func (u *deepLUsage) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := u.Refresh(); err != nil {
+			log.Printf("DeepL usage refresh failed: %v", err)
+		}
+	}
+}
+
+// MarkStreamStart は今の使用文字数を配信開始時点として記録します
+func (u *deepLUsage) MarkStreamStart() {
+	u.mu.Lock()
+	u.streamStart, u.streamSet = u.count, u.known
+	u.mu.Unlock()
+}
+
+// String は "12345/500000" 形式で返します（未取得なら "unknown"）
+func (u *deepLUsage) String() string {
+	if u == nil {
+		return "unknown"
+	}
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	if !u.known {
+		return "unknown"
+	}
+	return fmt.Sprintf("%d/%d", u.count, u.limit)
+}
+
+// StreamSummary は配信開始からの使用文字数を "this stream: 12,340 chars" 形式で返します
+func (u *deepLUsage) StreamSummary() string {
+	if u == nil {
+		return ""
+	}
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	if !u.known || !u.streamSet {
+		return ""
+	}
+	return fmt.Sprintf("this stream: %s chars", formatComma(u.count-u.streamStart))
+}
+
+// formatComma は 3桁ごとにカンマ区切りします
+func formatComma(n int) string {
+	s := strconv.Itoa(n)
+	if n < 0 {
+		return "-" + formatComma(-n)
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}